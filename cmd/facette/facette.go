@@ -78,11 +78,15 @@ func main() {
 
 	// Handle server signals
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGUSR1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGUSR1)
 
 	go func() {
 		for sig := range sigChan {
 			switch sig {
+			case syscall.SIGHUP:
+				instance.Reload()
+				break
+
 			case syscall.SIGUSR1:
 				instance.Refresh()
 				break
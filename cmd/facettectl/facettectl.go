@@ -13,7 +13,20 @@ const (
 	cmdUsage = `Usage: %s [OPTIONS] COMMAND
 
 Commands:
-   refresh  refresh server catalog and library`
+   refresh       refresh server catalog and library
+   reload        reload server providers definitions
+   filters test  trace records through a provider filters
+
+Refresh options:
+   -p NAME  only refresh catalog from NAME provider
+   -w       wait for provider refresh completion
+
+Filters test usage:
+   filters test PROVIDER [ORIGIN,]SOURCE,METRIC...
+   filters test -r PROVIDER
+
+Filters test options:
+   -r  trace records from a live provider refresh`
 
 	defaultConfigFile string = "/etc/facette/facette.json"
 )
@@ -59,8 +72,10 @@ func main() {
 	}
 
 	switch flag.Args()[0] {
-	case "refresh":
+	case "refresh", "reload":
 		handler = handleService
+	case "filters":
+		handler = handleFilters
 	default:
 		cmd.PrintUsage(os.Stderr, cmdUsage)
 		os.Exit(1)
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/facette/facette/pkg/config"
+)
+
+type filterTraceRecord struct {
+	Origin     string            `json:"origin"`
+	Source     string            `json:"source"`
+	Metric     string            `json:"metric"`
+	Attributes map[string]string `json:"attributes"`
+}
+
+type filterTrace struct {
+	Input  filterTraceRecord  `json:"input"`
+	Output *filterTraceRecord `json:"output"`
+	Kept   bool               `json:"kept"`
+	Steps  []struct {
+		Rule      int               `json:"rule"`
+		Action    string            `json:"action"`
+		Field     string            `json:"field"`
+		Before    string            `json:"before"`
+		After     string            `json:"after"`
+		Tags      map[string]string `json:"tags"`
+		Discarded bool              `json:"discarded"`
+	} `json:"steps"`
+}
+
+func handleFilters(config *config.Config, args []string) error {
+	cmd := &cmdServer{config: config}
+
+	if len(args) < 2 {
+		return os.ErrInvalid
+	}
+
+	switch args[1] {
+	case "test":
+		return cmd.testFilters(args[2:])
+	}
+
+	return os.ErrInvalid
+}
+
+func (cmd *cmdServer) testFilters(args []string) error {
+	var (
+		refresh  bool
+		response struct {
+			Message string         `json:"message"`
+			Traces  []*filterTrace `json:"traces"`
+			Error   string         `json:"error"`
+		}
+	)
+
+	flags := flag.NewFlagSet("filters test", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	flags.BoolVar(&refresh, "r", false, "")
+
+	if err := flags.Parse(args); err != nil || flags.NArg() == 0 || refresh == (flags.NArg() > 1) {
+		return os.ErrInvalid
+	}
+
+	name := flags.Arg(0)
+
+	// Parse sample records given as `[ORIGIN,]SOURCE,METRIC' arguments
+	records := make([]map[string]string, 0)
+
+	for _, arg := range flags.Args()[1:] {
+		parts := strings.Split(arg, ",")
+
+		switch len(parts) {
+		case 2:
+			records = append(records, map[string]string{"source": parts[0], "metric": parts[1]})
+		case 3:
+			records = append(records, map[string]string{"origin": parts[0], "source": parts[1], "metric": parts[2]})
+		default:
+			return fmt.Errorf("invalid record `%s'", arg)
+		}
+	}
+
+	data, _ := json.Marshal(map[string]interface{}{"records": records, "refresh": refresh})
+
+	client, baseURL := cmd.httpClient()
+
+	resp, err := client.Post(
+		baseURL+cmd.config.URLPrefix+"/api/v1/providers/"+url.PathEscape(name)+"/filters/test",
+		"application/json",
+		bytes.NewReader(data),
+	)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("unable to decode server response: %s", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusBadGateway:
+		for _, trace := range response.Traces {
+			printFilterTrace(trace)
+		}
+
+		if response.Error != "" {
+			return fmt.Errorf("unable to refresh `%s' provider: %s", name, response.Error)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("unable to test `%s' provider filters: %s", name, response.Message)
+}
+
+func printFilterTrace(trace *filterTrace) {
+	fmt.Printf("%s/%s/%s\n", trace.Input.Origin, trace.Input.Source, trace.Input.Metric)
+
+	for _, step := range trace.Steps {
+		switch {
+		case step.Discarded:
+			fmt.Printf("   rule #%d %s %s: %s (discarded)\n", step.Rule+1, step.Action, step.Field, step.Before)
+		case step.Tags != nil:
+			fmt.Printf("   rule #%d %s %s: %s [%s]\n", step.Rule+1, step.Action, step.Field, step.Before,
+				formatFilterTags(step.Tags))
+		default:
+			fmt.Printf("   rule #%d %s %s: %s -> %s\n", step.Rule+1, step.Action, step.Field, step.Before,
+				step.After)
+		}
+	}
+
+	if !trace.Kept {
+		fmt.Println("   => discarded")
+		return
+	}
+
+	fmt.Printf("   => kept as %s/%s/%s", trace.Output.Origin, trace.Output.Source, trace.Output.Metric)
+
+	if len(trace.Output.Attributes) > 0 {
+		fmt.Printf(" [%s]", formatFilterTags(trace.Output.Attributes))
+	}
+
+	fmt.Println()
+}
+
+func formatFilterTags(tags map[string]string) string {
+	result := make([]string, 0)
+	for key, value := range tags {
+		result = append(result, key+"="+value)
+	}
+
+	sort.Strings(result)
+
+	return strings.Join(result, ", ")
+}
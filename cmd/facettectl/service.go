@@ -1,8 +1,13 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
 	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -17,6 +22,8 @@ func handleService(config *config.Config, args []string) error {
 	switch args[0] {
 	case "refresh":
 		return cmd.refresh(args[1:])
+	case "reload":
+		return cmd.reload(args[1:])
 	}
 
 	return nil
@@ -27,10 +34,37 @@ type cmdServer struct {
 }
 
 func (cmd *cmdServer) refresh(args []string) error {
+	var (
+		providerName string
+		wait         bool
+	)
+
+	flags := flag.NewFlagSet("refresh", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	flags.StringVar(&providerName, "p", "", "")
+	flags.BoolVar(&wait, "w", false, "")
+
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 || wait && providerName == "" {
+		return os.ErrInvalid
+	}
+
+	// Refresh a single provider catalog using the server API
+	if providerName != "" {
+		return cmd.refreshProvider(providerName, wait)
+	}
+
+	return cmd.signal(syscall.SIGUSR1)
+}
+
+func (cmd *cmdServer) reload(args []string) error {
 	if len(args) > 0 {
 		return os.ErrInvalid
 	}
 
+	return cmd.signal(syscall.SIGHUP)
+}
+
+func (cmd *cmdServer) signal(sig syscall.Signal) error {
 	if cmd.config.PidFile == "" {
 		return fmt.Errorf("missing pid configuration")
 	} else if _, err := os.Stat(cmd.config.PidFile); os.IsNotExist(err) {
@@ -47,5 +81,90 @@ func (cmd *cmdServer) refresh(args []string) error {
 		return err
 	}
 
-	return syscall.Kill(pid, syscall.SIGUSR1)
+	return syscall.Kill(pid, sig)
+}
+
+func (cmd *cmdServer) refreshProvider(name string, wait bool) error {
+	var status struct {
+		Message         string  `json:"message"`
+		RefreshDuration float64 `json:"refresh_duration"`
+		RefreshRecords  int     `json:"refresh_records"`
+		RefreshError    string  `json:"refresh_error"`
+		Origins         int     `json:"origins"`
+		Sources         int     `json:"sources"`
+		Metrics         int     `json:"metrics"`
+	}
+
+	client, baseURL := cmd.httpClient()
+
+	query := url.Values{}
+	if wait {
+		query.Set("wait", "1")
+	}
+
+	response, err := client.Post(
+		baseURL+cmd.config.URLPrefix+"/api/v1/providers/"+url.PathEscape(name)+"/refresh?"+query.Encode(),
+		"application/json",
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusAccepted {
+		fmt.Printf("Refresh of `%s' provider scheduled\n", name)
+		return nil
+	}
+
+	if err := json.NewDecoder(response.Body).Decode(&status); err != nil {
+		return fmt.Errorf("unable to decode server response: %s", err)
+	}
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		fmt.Printf("Provider `%s' refreshed in %.3fs: %d records (%d origins, %d sources, %d metrics)\n", name,
+			status.RefreshDuration, status.RefreshRecords, status.Origins, status.Sources, status.Metrics)
+		return nil
+
+	case http.StatusBadGateway:
+		return fmt.Errorf("unable to refresh `%s' provider: %s", name, status.RefreshError)
+	}
+
+	return fmt.Errorf("unable to refresh `%s' provider: %s", name, status.Message)
+}
+
+func (cmd *cmdServer) httpClient() (*http.Client, string) {
+	netType := "tcp"
+	address := cmd.config.BindAddr
+
+	for _, scheme := range [...]string{"tcp", "tcp4", "tcp6", "unix"} {
+		prefix := scheme + "://"
+
+		if strings.HasPrefix(address, prefix) {
+			netType = scheme
+			address = strings.TrimPrefix(address, prefix)
+			break
+		}
+	}
+
+	// Connect through the server unix socket if any
+	if netType == "unix" {
+		return &http.Client{
+			Transport: &http.Transport{
+				Dial: func(network, addr string) (net.Conn, error) {
+					return net.Dial("unix", address)
+				},
+			},
+		}, "http://localhost"
+	}
+
+	// Use local address if server listens on all interfaces
+	host, port, err := net.SplitHostPort(address)
+	if err == nil && (host == "" || net.ParseIP(host) != nil && net.ParseIP(host).IsUnspecified()) {
+		address = net.JoinHostPort("localhost", port)
+	}
+
+	return &http.Client{}, "http://" + address
 }
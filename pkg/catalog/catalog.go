@@ -4,6 +4,7 @@ package catalog
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/facette/facette/pkg/logger"
 )
@@ -12,9 +13,25 @@ import (
 type Catalog struct {
 	RecordChan chan *Record
 	origins    map[string]*Origin
+	counters   map[interface{}]*connectorCounters
 	sync.RWMutex
 }
 
+// ConnectorStats represents the statistics of the catalog entries associated with a connector.
+type ConnectorStats struct {
+	Origins      int
+	Sources      int
+	Metrics      int
+	StaleMetrics int
+}
+
+type connectorCounters struct {
+	origins map[string]int
+	sources map[string]int
+	metrics int
+	stale   int
+}
+
 // Record represents a catalog record.
 type Record struct {
 	Origin         string
@@ -23,7 +40,27 @@ type Record struct {
 	OriginalOrigin string
 	OriginalSource string
 	OriginalMetric string
+	Attributes     map[string]string
 	Connector      interface{}
+	Generation     int
+	Stale          bool
+	flushed        chan struct{}
+}
+
+// NewFlushRecord creates a new flush record. Flush records carry no data: they are forwarded through the records
+// pipeline and notify upon insertion that all the records sent before them have reached the catalog.
+func NewFlushRecord() *Record {
+	return &Record{flushed: make(chan struct{})}
+}
+
+// IsFlush returns whether or not the record is a flush record.
+func (r *Record) IsFlush() bool {
+	return r.flushed != nil
+}
+
+// Flushed returns a channel closed once the flush record has been inserted in the catalog.
+func (r *Record) Flushed() <-chan struct{} {
+	return r.flushed
 }
 
 func (r Record) String() string {
@@ -35,11 +72,17 @@ func NewCatalog() *Catalog {
 	return &Catalog{
 		RecordChan: make(chan *Record),
 		origins:    make(map[string]*Origin),
+		counters:   make(map[interface{}]*connectorCounters),
 	}
 }
 
 // Insert inserts a new record in the catalog.
 func (c *Catalog) Insert(record *Record) {
+	if record.IsFlush() {
+		close(record.flushed)
+		return
+	}
+
 	c.Lock()
 	defer c.Unlock()
 
@@ -68,13 +111,138 @@ func (c *Catalog) Insert(record *Record) {
 		)
 	}
 
-	if _, ok := c.origins[record.Origin].sources[record.Source].metrics[record.Metric]; !ok {
+	if metric, ok := c.origins[record.Origin].sources[record.Source].metrics[record.Metric]; !ok {
 		c.origins[record.Origin].sources[record.Source].metrics[record.Metric] = NewMetric(
 			record.Metric,
 			record.OriginalMetric,
 			c.origins[record.Origin].sources[record.Source],
 			record.Connector,
 		)
+
+		metric = c.origins[record.Origin].sources[record.Source].metrics[record.Metric]
+		metric.attributes = record.Attributes
+		metric.generation = record.Generation
+		metric.lastSeen = time.Now()
+		metric.stale = record.Stale
+
+		c.count(metric, 1)
+	} else if !record.Stale && (metric.stale || metric.connector == record.Connector) {
+		// Metric either restored from a snapshot and now confirmed by its connector, or emitted again by it
+		c.count(metric, -1)
+
+		metric.OriginalName = record.OriginalMetric
+		metric.connector = record.Connector
+		metric.attributes = record.Attributes
+		metric.generation = record.Generation
+		metric.lastSeen = time.Now()
+		metric.stale = false
+
+		c.count(metric, 1)
+	}
+}
+
+// Purge removes all the entries inserted by a connector from the catalog, pruning emptied sources and origins.
+func (c *Catalog) Purge(connector interface{}) int {
+	return c.purge(func(metric *Metric) bool {
+		return metric.connector == connector
+	})
+}
+
+// PurgeStale removes the stale entries associated with a connector from the catalog, pruning emptied sources and
+// origins.
+func (c *Catalog) PurgeStale(connector interface{}) int {
+	return c.purge(func(metric *Metric) bool {
+		return metric.stale && metric.connector == connector
+	})
+}
+
+// Expire removes the entries associated with a connector that have not been emitted again since a given refresh
+// generation and for longer than the grace delay, pruning emptied sources and origins.
+func (c *Catalog) Expire(connector interface{}, generation int, delay time.Duration) int {
+	return c.purge(func(metric *Metric) bool {
+		return !metric.stale && metric.connector == connector && metric.generation < generation &&
+			time.Since(metric.lastSeen) >= delay
+	})
+}
+
+func (c *Catalog) purge(match func(*Metric) bool) int {
+	var count int
+
+	c.Lock()
+	defer c.Unlock()
+
+	for originName, origin := range c.origins {
+		for sourceName, source := range origin.sources {
+			for metricName, metric := range source.metrics {
+				if match(metric) {
+					c.count(metric, -1)
+					delete(source.metrics, metricName)
+					count++
+				}
+			}
+
+			if len(source.metrics) == 0 {
+				delete(origin.sources, sourceName)
+			}
+		}
+
+		if len(origin.sources) == 0 {
+			delete(c.origins, originName)
+		}
+	}
+
+	return count
+}
+
+// GetConnectorStats returns the statistics of the catalog entries associated with a connector.
+func (c *Catalog) GetConnectorStats(connector interface{}) ConnectorStats {
+	c.RLock()
+	defer c.RUnlock()
+
+	counters, ok := c.counters[connector]
+	if !ok {
+		return ConnectorStats{}
+	}
+
+	return ConnectorStats{
+		Origins:      len(counters.origins),
+		Sources:      len(counters.sources),
+		Metrics:      counters.metrics,
+		StaleMetrics: counters.stale,
+	}
+}
+
+// count updates the counters of the connector associated with a metric, keeping track of the origins and sources it
+// contributes to. It must be called with the catalog lock held.
+func (c *Catalog) count(metric *Metric, delta int) {
+	counters, ok := c.counters[metric.connector]
+	if !ok {
+		counters = &connectorCounters{
+			origins: make(map[string]int),
+			sources: make(map[string]int),
+		}
+
+		c.counters[metric.connector] = counters
+	}
+
+	originName := metric.source.origin.Name
+	sourceKey := originName + "\x1e" + metric.source.Name
+
+	if counters.origins[originName] += delta; counters.origins[originName] <= 0 {
+		delete(counters.origins, originName)
+	}
+
+	if counters.sources[sourceKey] += delta; counters.sources[sourceKey] <= 0 {
+		delete(counters.sources, sourceKey)
+	}
+
+	counters.metrics += delta
+	if metric.stale {
+		counters.stale += delta
+	}
+
+	if counters.metrics <= 0 {
+		delete(c.counters, metric.connector)
 	}
 }
 
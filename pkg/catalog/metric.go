@@ -1,11 +1,17 @@
 package catalog
 
+import "time"
+
 // Metric represents a metric entry.
 type Metric struct {
 	Name         string
 	OriginalName string
 	source       *Source
 	connector    interface{}
+	attributes   map[string]string
+	generation   int
+	lastSeen     time.Time
+	stale        bool
 }
 
 // NewMetric creates a new metric instance.
@@ -33,3 +39,19 @@ func (m *Metric) GetConnector() interface{} {
 
 	return m.connector
 }
+
+// GetAttributes returns the attributes attached to the metric by the provider filters.
+func (m *Metric) GetAttributes() map[string]string {
+	m.source.origin.catalog.RLock()
+	defer m.source.origin.catalog.RUnlock()
+
+	return m.attributes
+}
+
+// IsStale returns whether the metric has been restored from a snapshot and not yet confirmed by its connector.
+func (m *Metric) IsStale() bool {
+	m.source.origin.catalog.RLock()
+	defer m.source.origin.catalog.RUnlock()
+
+	return m.stale
+}
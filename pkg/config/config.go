@@ -29,70 +29,147 @@ const (
 	DefaultPidFile string = "/var/run/facette/facette.pid"
 	// DefaultPlotSample represents the default plot sample for graph querying.
 	DefaultPlotSample int = 400
+	// DefaultQueryWorkers represents the default maximum number of concurrent provider queries per plot request.
+	DefaultQueryWorkers int = 8
+	// DefaultCatalogSnapshotInterval represents the default catalog snapshot saving interval in seconds.
+	DefaultCatalogSnapshotInterval int = 300
+	// DefaultProviderExpireDelay represents the default delay in seconds after which catalog entries no longer
+	// emitted by a provider are removed.
+	DefaultProviderExpireDelay int = 3600
+	// DefaultPlotCacheTTL represents the default plot cache entries lifetime in seconds.
+	DefaultPlotCacheTTL int = 30
+	// DefaultPlotCacheHistoryTTL represents the default plot cache entries lifetime in seconds for past time windows.
+	DefaultPlotCacheHistoryTTL int = 3600
+	// DefaultPlotCacheMaxSize represents the default plot cache memory bound in megabytes.
+	DefaultPlotCacheMaxSize int = 64
 )
 
 // Config represents the global configuration of the instance.
 type Config struct {
-	BindAddr         string                     `json:"bind"`
-	SocketUser       int                        `json:"socket_user,string"`
-	SocketGroup      int                        `json:"socket_group,string"`
-	SocketMode       *string                    `json:"socket_mode"`
-	BaseDir          string                     `json:"base_dir"`
-	DataDir          string                     `json:"data_dir"`
-	ProvidersDir     string                     `json:"providers_dir"`
-	PidFile          string                     `json:"pid_file"`
-	URLPrefix        string                     `json:"url_prefix"`
-	ReadOnly         bool                       `json:"read_only"`
-	HideBuildDetails bool                       `json:"hide_build_details"`
-	Providers        map[string]*ProviderConfig `json:"-"`
+	BindAddr                string                     `json:"bind"`
+	SocketUser              int                        `json:"socket_user,string"`
+	SocketGroup             int                        `json:"socket_group,string"`
+	SocketMode              *string                    `json:"socket_mode"`
+	BaseDir                 string                     `json:"base_dir"`
+	DataDir                 string                     `json:"data_dir"`
+	ProvidersDir            string                     `json:"providers_dir"`
+	PidFile                 string                     `json:"pid_file"`
+	URLPrefix               string                     `json:"url_prefix"`
+	ReadOnly                bool                       `json:"read_only"`
+	HideBuildDetails        bool                       `json:"hide_build_details"`
+	QueryWorkers            int                        `json:"query_workers"`
+	PlotCache               *PlotCacheConfig           `json:"plot_cache"`
+	CatalogSnapshotInterval int                        `json:"catalog_snapshot_interval"`
+	Providers               map[string]*ProviderConfig `json:"-"`
 	sync.RWMutex
 }
 
+// PlotCacheConfig represents the plot cache configuration of the instance.
+type PlotCacheConfig struct {
+	TTL        int `json:"ttl"`
+	HistoryTTL int `json:"history_ttl"`
+	MaxSize    int `json:"max_size"`
+}
+
 // Load loads the configuration from the filesystem.
 func (config *Config) Load(filePath string) error {
-	var errOutput error
-
 	_, err := utils.JSONLoad(filePath, &config)
 	if err != nil {
 		return err
 	}
 
 	// Load provider definitions
-	config.Providers = make(map[string]*ProviderConfig)
+	providers, err := config.LoadProviders()
+	if err != nil {
+		return err
+	}
+
+	config.Lock()
+	config.Providers = providers
+	config.Unlock()
+
+	return nil
+}
+
+// LoadProviders loads the provider definitions from the filesystem.
+func (config *Config) LoadProviders() (map[string]*ProviderConfig, error) {
+	var (
+		filePaths []string
+		errOutput error
+	)
+
+	providers := make(map[string]*ProviderConfig)
+
+	// Lookup tables might be stored along with the definitions: resolve their paths prior to loading the definitions,
+	// so that tables are neither parsed as definitions nor shadow definitions sharing their name
+	tablePaths := make(map[string]bool)
 
 	walkFunc := func(filePath string, fileInfo os.FileInfo, err error) error {
+		var definition struct {
+			Filters []*ProviderFilterConfig `json:"filters"`
+		}
+
 		if fileInfo.IsDir() || !strings.HasSuffix(filePath, ".json") {
 			return nil
 		}
 
-		_, providerName := path.Split(strings.TrimSuffix(filePath, ".json"))
-
-		config.Lock()
-		defer config.Unlock()
+		filePaths = append(filePaths, path.Clean(filePath))
 
-		config.Providers[providerName] = &ProviderConfig{}
+		// Ignore decoding errors, as the file might not be a definition (they are reported upon actual loading)
+		utils.JSONLoad(filePath, &definition)
 
-		if fileInfo, err = utils.JSONLoad(filePath, config.Providers[providerName]); err != nil {
-			err = fmt.Errorf("in %s, %s", filePath, err)
-			if errOutput == nil {
-				errOutput = err
+		for _, filter := range definition.Filters {
+			if filter != nil && filter.File != "" {
+				tablePaths[filter.tablePath(path.Dir(filePath))] = true
 			}
-
-			return err
 		}
 
 		return nil
 	}
 
 	if err := utils.WalkDir(config.ProvidersDir, walkFunc); err != nil {
-		return fmt.Errorf("unable to load provider definitions: %s", err)
+		return nil, fmt.Errorf("unable to load provider definitions: %s", err)
+	}
+
+	for _, filePath := range filePaths {
+		if tablePaths[filePath] {
+			continue
+		}
+
+		_, providerName := path.Split(strings.TrimSuffix(filePath, ".json"))
+
+		providers[providerName] = &ProviderConfig{ExpireDelay: DefaultProviderExpireDelay}
+
+		if _, err := utils.JSONLoad(filePath, providers[providerName]); err != nil {
+			errOutput = fmt.Errorf("in %s, %s", filePath, err)
+			break
+		}
+
+		// Load filters lookup tables, so that their changes are also detected
+		for _, filter := range providers[providerName].Filters {
+			if filter.File == "" {
+				continue
+			}
+
+			if err := filter.loadTable(filter.tablePath(path.Dir(filePath))); err != nil {
+				errOutput = fmt.Errorf("in %s, %s", filePath, err)
+				break
+			}
+		}
+
+		if errOutput != nil {
+			break
+		}
+
+		// Keep track of the definition as loaded from the filesystem to detect further changes
+		providers[providerName].digest = providers[providerName].computeDigest()
 	}
 
 	if errOutput != nil {
-		return errOutput
+		return nil, errOutput
 	}
 
-	return nil
+	return providers, nil
 }
 
 func getSetting(config map[string]interface{}, setting string, kind reflect.Kind,
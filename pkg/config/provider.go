@@ -1,19 +1,79 @@
 package config
 
-import "regexp"
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+
+	"github.com/facette/facette/pkg/utils"
+)
 
 // ProviderConfig represents a provider definition in the configuration system.
 type ProviderConfig struct {
 	Connector       map[string]interface{}  `json:"connector"`
 	Filters         []*ProviderFilterConfig `json:"filters"`
 	RefreshInterval int                     `json:"refresh_interval"`
+	QueryTimeout    int                     `json:"query_timeout"`
+	ExpireDelay     int                     `json:"expire_delay"`
+	digest          string
+}
+
+// Equal returns whether two provider definitions were loaded identical from the filesystem.
+func (config *ProviderConfig) Equal(other *ProviderConfig) bool {
+	return config.digest != "" && config.digest == other.digest
+}
+
+func (config *ProviderConfig) computeDigest() string {
+	data, _ := json.Marshal(config)
+	return string(data)
 }
 
 // ProviderFilterConfig represents a filtering rule in an ProviderConfig instance.
 type ProviderFilterConfig struct {
-	Action        string         `json:"action"`
-	Pattern       string         `json:"pattern"`
+	Action        string                     `json:"action"`
+	Pattern       string                     `json:"pattern"`
+	Target        string                     `json:"target"`
+	Into          string                     `json:"into"`
+	Conditions    []*ProviderFilterCondition `json:"conditions"`
+	File          string                     `json:"file,omitempty"`
+	Table         map[string]string          `json:"table,omitempty"`
+	Tags          map[string]string          `json:"tags,omitempty"`
+	PatternRegexp *regexp.Regexp             `json:"-"`
+}
+
+// ProviderFilterCondition represents a condition to be satisfied by a record for a filtering rule to apply.
+type ProviderFilterCondition struct {
 	Target        string         `json:"target"`
-	Into          string         `json:"into"`
+	Pattern       string         `json:"pattern"`
 	PatternRegexp *regexp.Regexp `json:"-"`
 }
+
+func (config *ProviderFilterConfig) tablePath(dirPath string) string {
+	if path.IsAbs(config.File) {
+		return path.Clean(config.File)
+	}
+
+	return path.Join(dirPath, config.File)
+}
+
+func (config *ProviderFilterConfig) loadTable(filePath string) error {
+	var table map[string]string
+
+	if _, err := utils.JSONLoad(filePath, &table); err != nil {
+		return fmt.Errorf("unable to load `%s' lookup table: %s", config.File, err)
+	}
+
+	// Merge entries with the ones defined inline, the latter taking precedence
+	if config.Table == nil {
+		config.Table = make(map[string]string)
+	}
+
+	for key, value := range table {
+		if _, ok := config.Table[key]; !ok {
+			config.Table[key] = value
+		}
+	}
+
+	return nil
+}
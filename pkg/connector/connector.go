@@ -2,18 +2,35 @@
 package connector
 
 import (
+	"context"
 	"fmt"
 	"regexp"
+	"time"
 
 	"github.com/facette/facette/pkg/catalog"
+	"github.com/facette/facette/pkg/config"
+	"github.com/facette/facette/pkg/logger"
 	"github.com/facette/facette/pkg/plot"
 )
 
-// Connector represents the main interface of a connector handler.
+// Connector represents the main interface of a connector handler. Connectors must abort their in-flight backend
+// operations as soon as the context passed to GetPlots or Refresh is cancelled.
 type Connector interface {
 	GetName() string
-	GetPlots(query *plot.Query) ([]*plot.Series, error)
-	Refresh(string, chan<- *catalog.Record) error
+	GetPlots(ctx context.Context, query *plot.Query) ([]*plot.Series, error)
+	Refresh(ctx context.Context, originName string, outputChan chan<- *catalog.Record) error
+}
+
+// RefreshRequester represents a connector detecting backend changes by itself (e.g. upon plots retrieval), requesting
+// a catalog refresh using the function it is given.
+type RefreshRequester interface {
+	SetRefreshRequest(request func())
+}
+
+type metricAggregator struct {
+	pattern string
+	re      *regexp.Regexp
+	hook    interface{}
 }
 
 var (
@@ -70,3 +87,64 @@ func matchSeriesPattern(re *regexp.Regexp, series string) ([2]string, error) {
 
 	return [2]string{source, metric}, nil
 }
+
+func matchAggregatorPattern(aggregators []metricAggregator, metric string) interface{} {
+	if aggregators == nil {
+		return nil
+	}
+
+	for _, a := range aggregators {
+		if a.re.MatchString(metric) {
+			return a.hook
+		}
+	}
+
+	return nil
+}
+
+func compileAggregatorPatterns(aggregators interface{}, connectorType, connectorName string) []metricAggregator {
+	var (
+		re  *regexp.Regexp
+		err error
+	)
+
+	if aggregators == nil {
+		return nil
+	}
+
+	list := aggregators.([]interface{})
+	out := make([]metricAggregator, 0)
+
+	for _, a := range list {
+		aggregator := a.(map[string]interface{})
+
+		if re, err = regexp.Compile(aggregator["metric"].(string)); err != nil {
+			logger.Log(logger.LevelWarning, "connector", "%s[%s]: can't compile `%s', skipping", connectorType,
+				connectorName, aggregator["metric"].(string))
+			continue
+		}
+
+		out = append(out, metricAggregator{
+			pattern: aggregator["metric"].(string),
+			re:      re,
+			hook:    aggregator["aggregator"],
+		})
+	}
+
+	return out
+}
+
+// getPlotStep returns the plots step in seconds matching the requested sample over a time range, defaulting to the
+// configuration default sample.
+func getPlotStep(startTime, endTime time.Time, sample int) int {
+	if sample <= 0 {
+		sample = config.DefaultPlotSample
+	}
+
+	step := int(endTime.Sub(startTime).Seconds()) / sample
+	if step < 1 {
+		step = 1
+	}
+
+	return step
+}
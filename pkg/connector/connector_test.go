@@ -0,0 +1,58 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/facette/facette/pkg/catalog"
+	"github.com/facette/facette/pkg/plot"
+)
+
+func runTestRefresh(c Connector, originName string) []catalog.Record {
+	var records []catalog.Record
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	recordChan := make(chan *catalog.Record)
+
+	go func() {
+		defer wg.Done()
+
+		for record := range recordChan {
+			record.Connector = nil
+			records = append(records, *record)
+		}
+	}()
+
+	c.Refresh(context.Background(), originName, recordChan)
+	close(recordChan)
+
+	wg.Wait()
+
+	return records
+}
+
+func compareSeries(expected, actual []*plot.Series) error {
+	if len(expected) != len(actual) {
+		return fmt.Errorf("\nExpected %d series\nbut got  %d", len(expected), len(actual))
+	}
+
+	for i := range expected {
+		if expected[i].Name != actual[i].Name || expected[i].Step != actual[i].Step ||
+			len(expected[i].Plots) != len(actual[i].Plots) {
+			return fmt.Errorf("\nExpected %+v\nbut got  %+v", expected[i], actual[i])
+		}
+
+		for j := range expected[i].Plots {
+			if !expected[i].Plots[j].Time.Equal(actual[i].Plots[j].Time) ||
+				expected[i].Plots[j].Value.IsNaN() != actual[i].Plots[j].Value.IsNaN() ||
+				!expected[i].Plots[j].Value.IsNaN() && expected[i].Plots[j].Value != actual[i].Plots[j].Value {
+				return fmt.Errorf("\nExpected %v\nbut got  %v", expected[i].Plots, actual[i].Plots)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,539 @@
+// +build elasticsearch
+
+package connector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/facette/facette/pkg/catalog"
+	"github.com/facette/facette/pkg/config"
+	"github.com/facette/facette/pkg/logger"
+	"github.com/facette/facette/pkg/plot"
+	"github.com/facette/facette/pkg/utils"
+)
+
+const (
+	elasticsearchDefaultURL            string = "http://localhost:9200"
+	elasticsearchDefaultTimeout        int    = 10
+	elasticsearchDefaultLimit          int    = 1000
+	elasticsearchDefaultSourceField    string = "host"
+	elasticsearchDefaultTimestampField string = "@timestamp"
+	elasticsearchDefaultAggregator     string = "avg"
+	elasticsearchURLInfo               string = "/"
+	elasticsearchURLMapping            string = "/%s/_mapping"
+	elasticsearchURLSearch             string = "/%s/_search"
+)
+
+var (
+	elasticsearchAggregators = map[string]bool{
+		"avg":         true,
+		"cardinality": true,
+		"max":         true,
+		"sum":         true,
+	}
+
+	elasticsearchNumericTypes = map[string]bool{
+		"byte":         true,
+		"double":       true,
+		"float":        true,
+		"half_float":   true,
+		"integer":      true,
+		"long":         true,
+		"scaled_float": true,
+		"short":        true,
+	}
+)
+
+type elasticsearchErrorResponse struct {
+	Error struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error"`
+}
+
+type elasticsearchInfoResponse struct {
+	Version struct {
+		Distribution string `json:"distribution"`
+		Number       string `json:"number"`
+	} `json:"version"`
+}
+
+type elasticsearchSearchResponse struct {
+	Aggregations map[string]struct {
+		Buckets []map[string]interface{} `json:"buckets"`
+	} `json:"aggregations"`
+}
+
+type elasticsearchSeriesEntry struct {
+	field      string
+	aggregator string
+}
+
+// ElasticsearchConnector represents the main structure of the Elasticsearch connector.
+type ElasticsearchConnector struct {
+	name              string
+	url               string
+	timeout           int
+	insecureTLS       bool
+	username          string
+	password          string
+	index             string
+	limit             int
+	sourceField       string
+	timestampField    string
+	defaultAggregator string
+	aggregators       []metricAggregator
+	intervalParam     atomic.Value
+	series            map[string]map[string]elasticsearchSeriesEntry
+}
+
+func init() {
+	Connectors["elasticsearch"] = func(name string, settings map[string]interface{}) (Connector, error) {
+		var (
+			aggregators interface{}
+			err         error
+		)
+
+		c := &ElasticsearchConnector{
+			name:   name,
+			series: make(map[string]map[string]elasticsearchSeriesEntry),
+		}
+
+		c.intervalParam.Store("fixed_interval")
+
+		if c.url, err = config.GetString(settings, "url", false); err != nil {
+			return nil, err
+		}
+		if c.url == "" {
+			c.url = elasticsearchDefaultURL
+		}
+
+		if c.timeout, err = config.GetInt(settings, "timeout", false); err != nil {
+			return nil, err
+		}
+		if c.timeout <= 0 {
+			c.timeout = elasticsearchDefaultTimeout
+		}
+
+		if c.insecureTLS, err = config.GetBool(settings, "allow_insecure_tls", false); err != nil {
+			return nil, err
+		}
+
+		if c.username, err = config.GetString(settings, "username", false); err != nil {
+			return nil, err
+		}
+
+		if c.password, err = config.GetString(settings, "password", false); err != nil {
+			return nil, err
+		}
+
+		if c.index, err = config.GetString(settings, "index", true); err != nil {
+			return nil, err
+		}
+
+		if c.limit, err = config.GetInt(settings, "limit", false); err != nil {
+			return nil, err
+		}
+		if c.limit <= 0 {
+			c.limit = elasticsearchDefaultLimit
+		}
+
+		if c.sourceField, err = config.GetString(settings, "source_field", false); err != nil {
+			return nil, err
+		}
+		if c.sourceField == "" {
+			c.sourceField = elasticsearchDefaultSourceField
+		}
+
+		if c.timestampField, err = config.GetString(settings, "timestamp_field", false); err != nil {
+			return nil, err
+		}
+		if c.timestampField == "" {
+			c.timestampField = elasticsearchDefaultTimestampField
+		}
+
+		if c.defaultAggregator, err = config.GetString(settings, "default_aggregator", false); err != nil {
+			return nil, err
+		}
+		if c.defaultAggregator == "" {
+			c.defaultAggregator = elasticsearchDefaultAggregator
+		} else if !elasticsearchAggregators[c.defaultAggregator] {
+			return nil, fmt.Errorf("elasticsearch[%s]: unsupported aggregator `%s'", c.name, c.defaultAggregator)
+		}
+
+		if aggregators, err = config.GetJsonArray(settings, "aggregators", false); err != nil {
+			return nil, err
+		}
+
+		c.aggregators = compileAggregatorPatterns(aggregators, "elasticsearch", c.name)
+
+		return c, nil
+	}
+}
+
+// GetName returns the name of the current connector.
+func (c *ElasticsearchConnector) GetName() string {
+	return c.name
+}
+
+// GetPlots retrieves time series data from provider based on a query and a time interval.
+func (c *ElasticsearchConnector) GetPlots(ctx context.Context, query *plot.Query) ([]*plot.Series, error) {
+	var results []*plot.Series
+
+	if len(query.Series) == 0 {
+		return nil, fmt.Errorf("elasticsearch[%s]: requested series list is empty", c.name)
+	}
+
+	step := getPlotStep(query.StartTime, query.EndTime, query.Sample)
+
+	client := utils.NewHTTPClient(c.timeout, c.insecureTLS)
+
+	intervalParam := c.intervalParam.Load().(string)
+
+	for _, s := range query.Series {
+		var response elasticsearchSearchResponse
+
+		if _, ok := c.series[s.Source]; !ok {
+			return nil, fmt.Errorf("elasticsearch[%s]: unknown source `%s'", c.name, s.Source)
+		} else if _, ok := c.series[s.Source][s.Metric]; !ok {
+			return nil, fmt.Errorf("elasticsearch[%s]: unknown metric `%s' for source `%s'", c.name, s.Metric,
+				s.Source)
+		}
+
+		entry := c.series[s.Source][s.Metric]
+
+		body := map[string]interface{}{
+			"size": 0,
+			"query": map[string]interface{}{
+				"bool": map[string]interface{}{
+					"filter": []interface{}{
+						map[string]interface{}{
+							"term": map[string]interface{}{c.sourceField: s.Source},
+						},
+						map[string]interface{}{
+							"range": map[string]interface{}{
+								c.timestampField: map[string]interface{}{
+									"gte":    query.StartTime.Unix(),
+									"lte":    query.EndTime.Unix(),
+									"format": "epoch_second",
+								},
+							},
+						},
+					},
+				},
+			},
+			"aggs": map[string]interface{}{
+				"histogram": map[string]interface{}{
+					"date_histogram": map[string]interface{}{
+						"field":         c.timestampField,
+						intervalParam:   fmt.Sprintf("%ds", step),
+						"min_doc_count": 0,
+						"extended_bounds": map[string]interface{}{
+							"min": query.StartTime.Unix() * 1000,
+							"max": query.EndTime.Unix() * 1000,
+						},
+					},
+					"aggs": map[string]interface{}{
+						"value": map[string]interface{}{
+							entry.aggregator: map[string]interface{}{"field": entry.field},
+						},
+					},
+				},
+			},
+		}
+
+		if err := c.apiCall(ctx, client, "POST", fmt.Sprintf(elasticsearchURLSearch, c.index), body,
+			&response); err != nil {
+			return nil, err
+		}
+
+		series := &plot.Series{
+			Name: s.Name,
+			Step: step,
+		}
+
+		for _, bucket := range response.Aggregations["histogram"].Buckets {
+			p, err := elasticsearchParsePlot(bucket)
+			if err != nil {
+				return nil, fmt.Errorf("elasticsearch[%s]: unable to parse plot value: %s", c.name, err)
+			}
+
+			series.Plots = append(series.Plots, p)
+		}
+
+		results = append(results, series)
+	}
+
+	return results, nil
+}
+
+// Refresh triggers a full connector data update.
+func (c *ElasticsearchConnector) Refresh(ctx context.Context, originName string,
+	outputChan chan<- *catalog.Record) error {
+
+	var (
+		info     elasticsearchInfoResponse
+		mapping  map[string]struct{ Mappings map[string]interface{} }
+		response elasticsearchSearchResponse
+	)
+
+	client := utils.NewHTTPClient(c.timeout, c.insecureTLS)
+
+	// Request server version, as date histogram fixed intervals are only supported since release 7.2. Accounts
+	// lacking cluster monitoring privileges can't retrieve it: keep assuming a recent release in that case.
+	if err := c.apiCall(ctx, client, "GET", elasticsearchURLInfo, nil, &info); err != nil {
+		logger.Log(logger.LevelWarning, "connector", "%s, assuming date histogram fixed intervals support", err)
+	} else {
+		c.intervalParam.Store(elasticsearchGetIntervalParam(info.Version.Distribution, info.Version.Number))
+	}
+
+	// Request indices mapping to retrieve numeric fields
+	if err := c.apiCall(ctx, client, "GET", fmt.Sprintf(elasticsearchURLMapping, c.index), nil,
+		&mapping); err != nil {
+		return err
+	}
+
+	fieldsSet := make(map[string]bool)
+
+	for _, index := range mapping {
+		if properties, ok := index.Mappings["properties"].(map[string]interface{}); ok {
+			elasticsearchWalkMapping(properties, "", fieldsSet)
+			continue
+		}
+
+		// Handle mapping types from older releases
+		for _, mappingType := range index.Mappings {
+			if typeMapping, ok := mappingType.(map[string]interface{}); ok {
+				if properties, ok := typeMapping["properties"].(map[string]interface{}); ok {
+					elasticsearchWalkMapping(properties, "", fieldsSet)
+				}
+			}
+		}
+	}
+
+	delete(fieldsSet, c.sourceField)
+	delete(fieldsSet, c.timestampField)
+
+	fields := make([]string, 0)
+	for field := range fieldsSet {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+
+	if len(fields) == 0 {
+		logger.Log(logger.LevelWarning, "connector", "elasticsearch[%s]: no numeric field found in `%s'", c.name,
+			c.index)
+		return nil
+	}
+
+	// Request source values along with per-field documents count to only report fields each source has
+	fieldsAggs := make(map[string]interface{})
+	for i, field := range fields {
+		fieldsAggs[fmt.Sprintf("field%d", i)] = map[string]interface{}{
+			"value_count": map[string]interface{}{"field": field},
+		}
+	}
+
+	body := map[string]interface{}{
+		"size": 0,
+		"aggs": map[string]interface{}{
+			"sources": map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field": c.sourceField,
+					"size":  c.limit,
+				},
+				"aggs": fieldsAggs,
+			},
+		},
+	}
+
+	if err := c.apiCall(ctx, client, "POST", fmt.Sprintf(elasticsearchURLSearch, c.index), body,
+		&response); err != nil {
+		return err
+	}
+
+	for _, bucket := range response.Aggregations["sources"].Buckets {
+		sourceName, ok := bucket["key"].(string)
+		if !ok {
+			logger.Log(logger.LevelInfo, "connector", "elasticsearch[%s]: invalid source `%v', ignoring", c.name,
+				bucket["key"])
+			continue
+		}
+
+		for i, metricName := range fields {
+			if count, ok := bucket[fmt.Sprintf("field%d", i)].(map[string]interface{}); !ok {
+				continue
+			} else if value, _ := count["value"].(float64); value == 0 {
+				continue
+			}
+
+			aggregator := c.defaultAggregator
+			if hook, ok := matchAggregatorPattern(c.aggregators, metricName).(string); ok {
+				if !elasticsearchAggregators[hook] {
+					logger.Log(logger.LevelWarning, "connector", "elasticsearch[%s]: unsupported aggregator `%s', "+
+						"using `%s'", c.name, hook, aggregator)
+				} else {
+					aggregator = hook
+				}
+			}
+
+			if _, ok := c.series[sourceName]; !ok {
+				c.series[sourceName] = make(map[string]elasticsearchSeriesEntry)
+			}
+
+			c.series[sourceName][metricName] = elasticsearchSeriesEntry{
+				field:      metricName,
+				aggregator: aggregator,
+			}
+
+			outputChan <- &catalog.Record{
+				Origin:    originName,
+				Source:    sourceName,
+				Metric:    metricName,
+				Connector: c,
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *ElasticsearchConnector) apiCall(ctx context.Context, client *http.Client, method, path string,
+	body interface{}, result interface{}) error {
+
+	var buf []byte
+
+	apiURL := strings.TrimSuffix(c.url, "/") + path
+
+	if body != nil {
+		var err error
+
+		if buf, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("elasticsearch[%s]: unable to marshal JSON query: %s", c.name, err)
+		}
+	}
+
+	logger.Log(logger.LevelDebug, "connector", "elasticsearch[%s]: API Call to %s: %s", c.name, apiURL, string(buf))
+
+	r, err := http.NewRequestWithContext(ctx, method, apiURL, bytes.NewBuffer(buf))
+	if err != nil {
+		return fmt.Errorf("elasticsearch[%s]: unable to set up HTTP request: %s", c.name, err)
+	}
+
+	r.Header.Add("User-Agent", "Facette")
+	r.Header.Add("X-Requested-With", "ElasticsearchConnector")
+
+	if body != nil {
+		r.Header.Set("Content-Type", "application/json")
+	}
+
+	if c.username != "" {
+		r.SetBasicAuth(c.username, c.password)
+	}
+
+	rsp, err := client.Do(r)
+	if err != nil {
+		return fmt.Errorf("elasticsearch[%s]: unable to perform HTTP request: %s", c.name, err)
+	}
+	defer rsp.Body.Close()
+
+	data, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		return fmt.Errorf("elasticsearch[%s]: unable to read HTTP response body: %s", c.name, err)
+	}
+
+	if err = elasticsearchCheckBackendResponse(rsp); err != nil {
+		var errorResponse elasticsearchErrorResponse
+
+		// Try to report backend error reason if any
+		if json.Unmarshal(data, &errorResponse) == nil && errorResponse.Error.Reason != "" {
+			return fmt.Errorf("elasticsearch[%s]: backend returned an error: %s (%s)", c.name,
+				errorResponse.Error.Reason, errorResponse.Error.Type)
+		}
+
+		return fmt.Errorf("elasticsearch[%s]: invalid HTTP backend response: %s", c.name, err)
+	}
+
+	if err = json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("elasticsearch[%s]: unable to unmarshal JSON data: %s", c.name, err)
+	}
+
+	return nil
+}
+
+func elasticsearchGetIntervalParam(distribution, version string) string {
+	var major, minor int
+
+	// OpenSearch releases numbering restarted from 1.0, all of them supporting fixed intervals
+	if distribution == "opensearch" {
+		return "fixed_interval"
+	}
+
+	if _, err := fmt.Sscanf(version, "%d.%d", &major, &minor); err == nil && (major < 7 || major == 7 && minor < 2) {
+		return "interval"
+	}
+
+	return "fixed_interval"
+}
+
+func elasticsearchCheckBackendResponse(r *http.Response) error {
+	if r.StatusCode != 200 {
+		return fmt.Errorf("got HTTP status code %d, expected 200", r.StatusCode)
+	}
+
+	if utils.HTTPGetContentType(r) != "application/json" {
+		return fmt.Errorf("got HTTP content type `%s', expected `application/json'", r.Header["Content-Type"])
+	}
+
+	return nil
+}
+
+func elasticsearchParsePlot(bucket map[string]interface{}) (plot.Plot, error) {
+	timestamp, ok := bucket["key"].(float64)
+	if !ok {
+		return plot.Plot{}, fmt.Errorf("invalid bucket key `%v'", bucket["key"])
+	}
+
+	result := math.NaN()
+
+	if value, ok := bucket["value"].(map[string]interface{}); ok && value["value"] != nil {
+		if result, ok = value["value"].(float64); !ok {
+			return plot.Plot{}, fmt.Errorf("invalid value `%v'", value["value"])
+		}
+	}
+
+	return plot.Plot{
+		Time:  time.Unix(int64(timestamp)/1000, 0),
+		Value: plot.Value(result),
+	}, nil
+}
+
+func elasticsearchWalkMapping(properties map[string]interface{}, prefix string, fields map[string]bool) {
+	for name, definition := range properties {
+		entry, ok := definition.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		// Walk through object fields sub-properties
+		if subProperties, ok := entry["properties"].(map[string]interface{}); ok {
+			elasticsearchWalkMapping(subProperties, prefix+name+".", fields)
+			continue
+		}
+
+		if fieldType, _ := entry["type"].(string); elasticsearchNumericTypes[fieldType] {
+			fields[prefix+name] = true
+		}
+	}
+}
@@ -0,0 +1,202 @@
+// +build elasticsearch
+
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/facette/facette/pkg/catalog"
+	"github.com/facette/facette/pkg/plot"
+)
+
+func Test_ElasticsearchRefresh(test *testing.T) {
+	expected := []catalog.Record{
+		{Origin: "elasticsearch", Source: "app1", Metric: "http.latency"},
+		{Origin: "elasticsearch", Source: "app1", Metric: "http.requests"},
+		{Origin: "elasticsearch", Source: "app2", Metric: "http.requests"},
+	}
+
+	backend := httptest.NewServer(newElasticsearchTestHandler(`{"number":"7.10.2"}`, "fixed_interval"))
+	defer backend.Close()
+
+	c, err := Connectors["elasticsearch"]("elasticsearch", elasticsearchTestSettings(backend.URL))
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	actual := runTestRefresh(c, "elasticsearch")
+
+	if !reflect.DeepEqual(expected, actual) {
+		test.Logf("\nExpected %s\nbut got  %s", expected, actual)
+		test.Fail()
+	}
+}
+
+func Test_ElasticsearchGetPlots(test *testing.T) {
+	expected := []*plot.Series{
+		{Name: "series0", Step: 60, Plots: []plot.Plot{
+			{Time: time.Unix(0, 0), Value: 42},
+			{Time: time.Unix(60, 0), Value: plot.Value(math.NaN())},
+			{Time: time.Unix(120, 0), Value: 17},
+		}},
+	}
+
+	backend := httptest.NewServer(newElasticsearchTestHandler(`{"number":"7.10.2"}`, "fixed_interval"))
+	defer backend.Close()
+
+	c, err := Connectors["elasticsearch"]("elasticsearch", elasticsearchTestSettings(backend.URL))
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	runTestRefresh(c, "elasticsearch")
+
+	actual, err := c.GetPlots(context.Background(), &plot.Query{
+		StartTime: time.Unix(0, 0),
+		EndTime:   time.Unix(180, 0),
+		Sample:    3,
+		Series: []plot.QuerySeries{
+			{Name: "series0", Origin: "elasticsearch", Source: "app1", Metric: "http.requests"},
+		},
+	})
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	if err := compareSeries(expected, actual); err != nil {
+		test.Log(err)
+		test.Fail()
+	}
+}
+
+func Test_ElasticsearchGetPlotsInterval(test *testing.T) {
+	for _, entry := range []struct {
+		version       string
+		intervalParam string
+	}{
+		// Older releases only support the `interval' date histogram parameter
+		{`{"number":"6.8.23"}`, "interval"},
+		{`{"number":"7.2.0"}`, "fixed_interval"},
+		// OpenSearch numbering restarted from 1.0, all of its releases supporting fixed intervals
+		{`{"distribution":"opensearch","number":"2.11.0"}`, "fixed_interval"},
+		// Server version can't be retrieved without cluster monitoring privileges
+		{"", "fixed_interval"},
+	} {
+		backend := httptest.NewServer(newElasticsearchTestHandler(entry.version, entry.intervalParam))
+
+		c, err := Connectors["elasticsearch"]("elasticsearch", elasticsearchTestSettings(backend.URL))
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		if records := runTestRefresh(c, "elasticsearch"); len(records) != 3 {
+			test.Logf("\nExpected 3 records for %q version\nbut got  %d", entry.version, len(records))
+			test.Fail()
+		}
+
+		if _, err := c.GetPlots(context.Background(), &plot.Query{
+			StartTime: time.Unix(0, 0),
+			EndTime:   time.Unix(180, 0),
+			Sample:    3,
+			Series: []plot.QuerySeries{
+				{Name: "series0", Origin: "elasticsearch", Source: "app1", Metric: "http.requests"},
+			},
+		}); err != nil {
+			test.Logf("\nExpected `%s' parameter for %q version\nbut got  %s", entry.intervalParam, entry.version,
+				err)
+			test.Fail()
+		}
+
+		backend.Close()
+	}
+}
+
+func elasticsearchTestSettings(url string) map[string]interface{} {
+	return map[string]interface{}{
+		"url":          url,
+		"index":        "telemetry-*",
+		"source_field": "app",
+		"aggregators": []interface{}{
+			map[string]interface{}{"metric": "requests$", "aggregator": "sum"},
+		},
+	}
+}
+
+func newElasticsearchTestHandler(version, intervalParam string) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		elasticsearchTestHandler(writer, request, version, intervalParam)
+	}
+}
+
+func elasticsearchTestHandler(writer http.ResponseWriter, request *http.Request, version, intervalParam string) {
+	var (
+		body map[string]interface{}
+		data string
+	)
+
+	json.NewDecoder(request.Body).Decode(&body)
+
+	switch {
+	case request.Method == "GET" && request.URL.Path == "/":
+		if version == "" {
+			writer.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			writer.WriteHeader(http.StatusForbidden)
+			writer.Write([]byte(`{"error":{"type":"security_exception","reason":"action [cluster:monitor/main] ` +
+				`is unauthorized"},"status":403}`))
+			return
+		}
+
+		data = `{"name":"node1","version":` + version + `}`
+
+	case request.Method == "GET" && request.URL.Path == "/telemetry-*/_mapping":
+		data = `{"telemetry-2015.01.01":{"mappings":{"properties":{
+			"@timestamp":{"type":"date"},
+			"app":{"type":"keyword"},
+			"message":{"type":"text"},
+			"http":{"properties":{"latency":{"type":"float"},"requests":{"type":"long"},"path":{"type":"keyword"}}}
+		}}}}`
+
+	case request.Method == "POST" && request.URL.Path == "/telemetry-*/_search" && body["aggs"] != nil:
+		aggs := body["aggs"].(map[string]interface{})
+
+		if sources, ok := aggs["sources"].(map[string]interface{}); ok {
+			if sources["terms"].(map[string]interface{})["field"] != "app" {
+				break
+			}
+
+			data = `{"aggregations":{"sources":{"buckets":[
+				{"key":"app1","doc_count":10,"field0":{"value":4},"field1":{"value":10}},
+				{"key":"app2","doc_count":5,"field0":{"value":0},"field1":{"value":5}}
+			]}}}`
+		} else if histogram, ok := aggs["histogram"].(map[string]interface{}); ok {
+			if !reflect.DeepEqual(histogram["aggs"], map[string]interface{}{
+				"value": map[string]interface{}{"sum": map[string]interface{}{"field": "http.requests"}},
+			}) || histogram["date_histogram"].(map[string]interface{})[intervalParam] != "60s" {
+				break
+			}
+
+			data = `{"aggregations":{"histogram":{"buckets":[
+				{"key":0,"doc_count":3,"value":{"value":42}},
+				{"key":60000,"doc_count":0,"value":{"value":null}},
+				{"key":120000,"doc_count":1,"value":{"value":17}}
+			]}}}`
+		}
+	}
+
+	writer.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if data == "" {
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write([]byte(`{"error":{"type":"parsing_exception","reason":"unexpected query"},"status":400}`))
+		return
+	}
+
+	writer.Write([]byte(data))
+}
@@ -4,6 +4,7 @@ package connector
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -98,7 +99,7 @@ func (c *FacetteConnector) GetName() string {
 }
 
 // GetPlots retrieves time series data from origin based on a query and a time interval.
-func (c *FacetteConnector) GetPlots(query *plot.Query) ([]*plot.Series, error) {
+func (c *FacetteConnector) GetPlots(ctx context.Context, query *plot.Query) ([]*plot.Series, error) {
 	var results []*plot.Series
 
 	// Convert plotQuery into plotRequest-like to forward query to upstream Facette API
@@ -139,7 +140,8 @@ func (c *FacetteConnector) GetPlots(query *plot.Query) ([]*plot.Series, error) {
 
 	client := utils.NewHTTPClient(c.timeout, c.insecureTLS)
 
-	r, err := http.NewRequest("POST", strings.TrimSuffix(c.upstream, "/")+facetteURLPlots, bytes.NewReader(body))
+	r, err := http.NewRequestWithContext(ctx, "POST", strings.TrimSuffix(c.upstream, "/")+facetteURLPlots,
+		bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("facette[%s]: unable to set up HTTP request: %s", c.name, err)
 	}
@@ -186,10 +188,10 @@ func (c *FacetteConnector) GetPlots(query *plot.Query) ([]*plot.Series, error) {
 }
 
 // Refresh triggers a full connector data update.
-func (c *FacetteConnector) Refresh(originName string, outputChan chan<- *catalog.Record) error {
+func (c *FacetteConnector) Refresh(ctx context.Context, originName string, outputChan chan<- *catalog.Record) error {
 	client := utils.NewHTTPClient(c.timeout, c.insecureTLS)
 
-	r, err := http.NewRequest("GET", strings.TrimSuffix(c.upstream, "/")+facetteURLCatalog, nil)
+	r, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(c.upstream, "/")+facetteURLCatalog, nil)
 	if err != nil {
 		return fmt.Errorf("facette[%s]: unable to set up HTTP request: %s", c.name, err)
 	}
@@ -0,0 +1,536 @@
+// +build file
+
+package connector
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/facette/facette/pkg/catalog"
+	"github.com/facette/facette/pkg/config"
+	"github.com/facette/facette/pkg/logger"
+	"github.com/facette/facette/pkg/plot"
+	"github.com/facette/facette/pkg/utils"
+)
+
+const (
+	fileDefaultSourceColumn  string = "source"
+	fileDefaultMetricColumn  string = "metric"
+	fileDefaultTimeColumn    string = "time"
+	fileDefaultValueColumn   string = "value"
+	fileDefaultTimeFormat    string = "unix"
+	fileDefaultCheckInterval int    = 10
+)
+
+type fileRow struct {
+	source string
+	metric string
+	plot   plot.Plot
+}
+
+// FileConnector represents the main structure of the flat-file dataset connector.
+type FileConnector struct {
+	name          string
+	path          string
+	sourceColumn  string
+	metricColumn  string
+	timeColumn    string
+	valueColumn   string
+	timeFormat    string
+	checkInterval time.Duration
+	lastCheck     time.Time
+	files         map[string]time.Time
+	index         map[string]map[string][]plot.Plot
+	refresh       func()
+	sync.Mutex
+}
+
+func init() {
+	Connectors["file"] = func(name string, settings map[string]interface{}) (Connector, error) {
+		var (
+			checkInterval int
+			err           error
+		)
+
+		c := &FileConnector{
+			name:  name,
+			files: make(map[string]time.Time),
+			index: make(map[string]map[string][]plot.Plot),
+		}
+
+		if c.path, err = config.GetString(settings, "path", true); err != nil {
+			return nil, err
+		}
+		c.path = strings.TrimRight(c.path, "/")
+		if c.path == "" {
+			c.path = "."
+		}
+
+		if c.sourceColumn, err = config.GetString(settings, "source_column", false); err != nil {
+			return nil, err
+		}
+		if c.metricColumn, err = config.GetString(settings, "metric_column", false); err != nil {
+			return nil, err
+		}
+		if c.timeColumn, err = config.GetString(settings, "time_column", false); err != nil {
+			return nil, err
+		}
+		if c.valueColumn, err = config.GetString(settings, "value_column", false); err != nil {
+			return nil, err
+		}
+		if c.timeFormat, err = config.GetString(settings, "time_format", false); err != nil {
+			return nil, err
+		}
+		if checkInterval, err = config.GetInt(settings, "check_interval", false); err != nil {
+			return nil, err
+		}
+
+		// Enforce columns mapping defaults
+		if c.sourceColumn == "" {
+			c.sourceColumn = fileDefaultSourceColumn
+		}
+		if c.metricColumn == "" {
+			c.metricColumn = fileDefaultMetricColumn
+		}
+		if c.timeColumn == "" {
+			c.timeColumn = fileDefaultTimeColumn
+		}
+		if c.valueColumn == "" {
+			c.valueColumn = fileDefaultValueColumn
+		}
+		if c.timeFormat == "" {
+			c.timeFormat = fileDefaultTimeFormat
+		}
+		if checkInterval <= 0 {
+			checkInterval = fileDefaultCheckInterval
+		}
+
+		c.checkInterval = time.Duration(checkInterval) * time.Second
+
+		return c, nil
+	}
+}
+
+// GetName returns the name of the current connector.
+func (c *FileConnector) GetName() string {
+	return c.name
+}
+
+// SetRefreshRequest sets the function requesting a catalog refresh, called when dataset files modifications are
+// detected upon plots retrieval.
+func (c *FileConnector) SetRefreshRequest(request func()) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.refresh = request
+}
+
+// GetPlots retrieves time series data from origin based on a query and a time interval.
+func (c *FileConnector) GetPlots(ctx context.Context, query *plot.Query) ([]*plot.Series, error) {
+	var results []*plot.Series
+
+	if len(query.Series) == 0 {
+		return nil, fmt.Errorf("file[%s]: requested series list is empty", c.name)
+	}
+
+	// Reload index if dataset files have been modified since last indexing, requesting a catalog refresh as
+	// sources and metrics might have changed as well
+	if c.isModified() {
+		logger.Log(logger.LevelInfo, "connector", "file[%s]: dataset files modified, reloading index", c.name)
+
+		c.Lock()
+		err := c.reload(ctx)
+		refresh := c.refresh
+		c.Unlock()
+
+		if err != nil {
+			return nil, err
+		} else if refresh != nil {
+			refresh()
+		}
+	}
+
+	step := getPlotStep(query.StartTime, query.EndTime, query.Sample)
+
+	c.Lock()
+	defer c.Unlock()
+
+	for _, s := range query.Series {
+		if _, ok := c.index[s.Source]; !ok {
+			return nil, fmt.Errorf("file[%s]: unknown source `%s'", c.name, s.Source)
+		} else if _, ok := c.index[s.Source][s.Metric]; !ok {
+			return nil, fmt.Errorf("file[%s]: unknown metric `%s' for source `%s'", c.name, s.Metric, s.Source)
+		}
+
+		series := fileConsolidatePlots(c.index[s.Source][s.Metric], query.StartTime, query.EndTime, step)
+		series.Name = s.Name
+
+		results = append(results, series)
+	}
+
+	return results, nil
+}
+
+// Refresh triggers a full connector data update.
+func (c *FileConnector) Refresh(ctx context.Context, originName string, outputChan chan<- *catalog.Record) error {
+	c.Lock()
+
+	if err := c.reload(ctx); err != nil {
+		c.Unlock()
+		return err
+	}
+
+	records := make([]*catalog.Record, 0)
+
+	for sourceName, metrics := range c.index {
+		for metricName := range metrics {
+			records = append(records, &catalog.Record{
+				Origin:    originName,
+				Source:    sourceName,
+				Metric:    metricName,
+				Connector: c,
+			})
+		}
+	}
+
+	c.Unlock()
+
+	for _, record := range records {
+		outputChan <- record
+	}
+
+	return nil
+}
+
+// isModified returns whether or not dataset files have been added, modified or removed since last indexing. Files
+// are checked at most once per check interval, walking the dataset directory without holding the connector lock.
+func (c *FileConnector) isModified() bool {
+	c.Lock()
+
+	if time.Since(c.lastCheck) < c.checkInterval {
+		c.Unlock()
+		return false
+	}
+
+	c.lastCheck = time.Now()
+	indexed := c.files
+
+	c.Unlock()
+
+	files := make(map[string]time.Time)
+
+	walkFunc := func(filePath string, fileInfo os.FileInfo, err error) error {
+		if err != nil || fileInfo.Mode()&os.ModeType != 0 || fileGetFormat(filePath) == "" {
+			return nil
+		}
+
+		files[filePath] = fileInfo.ModTime()
+
+		return nil
+	}
+
+	if err := utils.WalkDir(c.path, walkFunc); err != nil || len(files) != len(indexed) {
+		return true
+	}
+
+	for filePath, modTime := range files {
+		if indexedTime, ok := indexed[filePath]; !ok || !indexedTime.Equal(modTime) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *FileConnector) reload(ctx context.Context) error {
+	files := make(map[string]time.Time)
+	index := make(map[string]map[string][]plot.Plot)
+
+	walkFunc := func(filePath string, fileInfo os.FileInfo, err error) error {
+		var rows []fileRow
+
+		// Stop indexing if operation has been cancelled
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// Report errors
+		if err != nil {
+			logger.Log(logger.LevelWarning, "connector", "file[%s]: error while walking: %s", c.name, err)
+			return nil
+		}
+
+		// Skip non-files and unsupported file formats
+		if fileInfo.Mode()&os.ModeType != 0 {
+			return nil
+		}
+
+		format := fileGetFormat(filePath)
+		if format == "" {
+			logger.Log(logger.LevelInfo, "connector", "file[%s]: unsupported file `%s', ignoring", c.name, filePath)
+			return nil
+		}
+
+		files[filePath] = fileInfo.ModTime()
+
+		fd, err := os.Open(filePath)
+		if err != nil {
+			logger.Log(logger.LevelWarning, "connector", "file[%s]: %s", c.name, err)
+			return nil
+		}
+		defer fd.Close()
+
+		if format == "csv" {
+			rows, err = c.parseCSV(fd, filePath)
+		} else {
+			rows, err = c.parseJSON(fd, filePath)
+		}
+
+		if err != nil {
+			logger.Log(logger.LevelWarning, "connector", "file[%s]: in `%s', %s", c.name, filePath, err)
+			return nil
+		}
+
+		for _, row := range rows {
+			if _, ok := index[row.source]; !ok {
+				index[row.source] = make(map[string][]plot.Plot)
+			}
+
+			index[row.source][row.metric] = append(index[row.source][row.metric], row.plot)
+		}
+
+		return nil
+	}
+
+	if err := utils.WalkDir(c.path, walkFunc); err != nil {
+		return fmt.Errorf("file[%s]: unable to index files: %s", c.name, err)
+	}
+
+	for _, metrics := range index {
+		for _, plots := range metrics {
+			sort.Sort(filePlotList(plots))
+		}
+	}
+
+	c.files = files
+	c.index = index
+	c.lastCheck = time.Now()
+
+	return nil
+}
+
+func (c *FileConnector) parseCSV(reader io.Reader, filePath string) ([]fileRow, error) {
+	var rows []fileRow
+
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read header: %s", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+
+	for _, name := range []string{c.sourceColumn, c.metricColumn, c.timeColumn, c.valueColumn} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing `%s' column", name)
+		}
+	}
+
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if perr, ok := err.(*csv.ParseError); ok {
+			c.skipLine(filePath, perr.StartLine, perr.Err)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		line, _ := csvReader.FieldPos(0)
+
+		if len(record) != len(header) {
+			c.skipLine(filePath, line, fmt.Errorf("got %d fields, expected %d", len(record), len(header)))
+			continue
+		}
+
+		row, err := c.parseRow(
+			record[columns[c.sourceColumn]],
+			record[columns[c.metricColumn]],
+			record[columns[c.timeColumn]],
+			record[columns[c.valueColumn]],
+		)
+		if err != nil {
+			c.skipLine(filePath, line, err)
+			continue
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func (c *FileConnector) parseJSON(reader io.Reader, filePath string) ([]fileRow, error) {
+	var rows []fileRow
+
+	scanner := bufio.NewScanner(reader)
+
+scanLines:
+	for line := 1; scanner.Scan(); line++ {
+		var (
+			entry  map[string]interface{}
+			fields [4]string
+		)
+
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			c.skipLine(filePath, line, err)
+			continue
+		}
+
+		for i, name := range []string{c.sourceColumn, c.metricColumn, c.timeColumn, c.valueColumn} {
+			switch value := entry[name].(type) {
+			case nil:
+				fields[i] = ""
+			case string:
+				fields[i] = value
+			case float64:
+				fields[i] = strconv.FormatFloat(value, 'f', -1, 64)
+			default:
+				c.skipLine(filePath, line, fmt.Errorf("invalid `%s' field", name))
+				continue scanLines
+			}
+		}
+
+		row, err := c.parseRow(fields[0], fields[1], fields[2], fields[3])
+		if err != nil {
+			c.skipLine(filePath, line, err)
+			continue
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, scanner.Err()
+}
+
+func (c *FileConnector) skipLine(filePath string, line int, err error) {
+	logger.Log(logger.LevelWarning, "connector", "file[%s]: in `%s', line %d: %s, skipping", c.name, filePath, line,
+		err)
+}
+
+func (c *FileConnector) parseRow(source, metric, timeValue, value string) (fileRow, error) {
+	var (
+		row = fileRow{source: source, metric: metric}
+		err error
+	)
+
+	if source == "" || metric == "" {
+		return row, fmt.Errorf("empty source or metric")
+	}
+
+	if c.timeFormat == "unix" {
+		var timestamp float64
+
+		if timestamp, err = strconv.ParseFloat(timeValue, 64); err != nil {
+			return row, fmt.Errorf("invalid time `%s'", timeValue)
+		}
+
+		row.plot.Time = time.Unix(int64(timestamp), 0)
+	} else if row.plot.Time, err = time.Parse(c.timeFormat, timeValue); err != nil {
+		return row, fmt.Errorf("invalid time `%s'", timeValue)
+	}
+
+	if value == "" {
+		row.plot.Value = plot.Value(math.NaN())
+	} else {
+		result, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return row, fmt.Errorf("invalid value `%s'", value)
+		}
+
+		row.plot.Value = plot.Value(result)
+	}
+
+	return row, nil
+}
+
+// fileConsolidatePlots averages the valid values of time-sorted plots within each step of a time range, gaps being
+// reported as NaN values, so that series are spaced by their step as for time series backends.
+func fileConsolidatePlots(plots []plot.Plot, startTime, endTime time.Time, step int) *plot.Series {
+	series := &plot.Series{Step: step}
+
+	duration := time.Duration(step) * time.Second
+
+	// Plots are sorted by time: look up the requested time range start boundary
+	i := sort.Search(len(plots), func(i int) bool { return !plots[i].Time.Before(startTime) })
+
+	for t := startTime; !t.After(endTime); t = t.Add(duration) {
+		var sum, count float64
+
+		for ; i < len(plots) && plots[i].Time.Before(t.Add(duration)) && !plots[i].Time.After(endTime); i++ {
+			if !plots[i].Value.IsNaN() {
+				sum += float64(plots[i].Value)
+				count++
+			}
+		}
+
+		value := plot.Value(math.NaN())
+		if count > 0 {
+			value = plot.Value(sum / count)
+		}
+
+		series.Plots = append(series.Plots, plot.Plot{Time: t, Value: value})
+	}
+
+	return series
+}
+
+func fileGetFormat(filePath string) string {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".csv":
+		return "csv"
+	case ".json", ".jsonl", ".ndjson":
+		return "json"
+	}
+
+	return ""
+}
+
+type filePlotList []plot.Plot
+
+func (l filePlotList) Len() int {
+	return len(l)
+}
+
+func (l filePlotList) Less(i, j int) bool {
+	return l[i].Time.Before(l[j].Time)
+}
+
+func (l filePlotList) Swap(i, j int) {
+	l[i], l[j] = l[j], l[i]
+}
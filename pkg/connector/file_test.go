@@ -0,0 +1,295 @@
+// +build file
+
+package connector
+
+import (
+	"context"
+	"io/ioutil"
+	"math"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/facette/facette/pkg/catalog"
+	"github.com/facette/facette/pkg/plot"
+)
+
+func Test_FileRefresh(test *testing.T) {
+	expected := []catalog.Record{
+		{Origin: "file", Source: "host1", Metric: "cpu.idle"},
+		{Origin: "file", Source: "host1", Metric: "load.shortterm"},
+		{Origin: "file", Source: "host2", Metric: "cpu.idle"},
+	}
+
+	dirPath, err := createTestFileDataset()
+	if err != nil {
+		test.Fatal(err)
+	}
+	defer os.RemoveAll(dirPath)
+
+	c, err := Connectors["file"]("file", map[string]interface{}{"path": dirPath})
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	actual := runTestRefresh(c, "file")
+
+	sort.Sort(fileTestRecordList(actual))
+
+	if !reflect.DeepEqual(expected, actual) {
+		test.Logf("\nExpected %s\nbut got  %s", expected, actual)
+		test.Fail()
+	}
+}
+
+func Test_FileGetPlots(test *testing.T) {
+	dirPath, err := createTestFileDataset()
+	if err != nil {
+		test.Fatal(err)
+	}
+	defer os.RemoveAll(dirPath)
+
+	c, err := Connectors["file"]("file", map[string]interface{}{"path": dirPath})
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	runTestRefresh(c, "file")
+
+	refreshRequests := 0
+	c.(RefreshRequester).SetRefreshRequest(func() { refreshRequests++ })
+
+	query := &plot.Query{
+		StartTime: time.Unix(60, 0),
+		EndTime:   time.Unix(180, 0),
+		Sample:    2,
+		Series: []plot.QuerySeries{
+			{Name: "series0", Origin: "file", Source: "host1", Metric: "cpu.idle"},
+			{Name: "series1", Origin: "file", Source: "host1", Metric: "load.shortterm"},
+		},
+	}
+
+	expected := []*plot.Series{
+		{Name: "series0", Step: 60, Plots: []plot.Plot{
+			{Time: time.Unix(60, 0), Value: 92},
+			{Time: time.Unix(120, 0), Value: plot.Value(math.NaN())},
+			{Time: time.Unix(180, 0), Value: 95},
+		}},
+		{Name: "series1", Step: 60, Plots: []plot.Plot{
+			{Time: time.Unix(60, 0), Value: 0.5},
+			{Time: time.Unix(120, 0), Value: plot.Value(math.NaN())},
+			{Time: time.Unix(180, 0), Value: plot.Value(math.NaN())},
+		}},
+	}
+
+	actual, err := c.GetPlots(context.Background(), query)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	if err := compareSeries(expected, actual); err != nil {
+		test.Log(err)
+		test.Fail()
+	}
+
+	// Update dataset file and check for index reload
+	filePath := path.Join(dirPath, "load.jsonl")
+
+	if err := ioutil.WriteFile(filePath, []byte(`{"source": "host1", "metric": "load.shortterm", "time": 60, `+
+		`"value": 1.5}`+"\n"), 0644); err != nil {
+		test.Fatal(err)
+	}
+
+	os.Chtimes(filePath, time.Now(), time.Now().Add(time.Minute))
+
+	// Files are checked at most once per check interval: index must not be reloaded yet
+	actual, err = c.GetPlots(context.Background(), query)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	if err := compareSeries(expected, actual); err != nil {
+		test.Log(err)
+		test.Fail()
+	}
+
+	if refreshRequests != 0 {
+		test.Logf("\nExpected 0 refresh request\nbut got  %d", refreshRequests)
+		test.Fail()
+	}
+
+	c.(*FileConnector).lastCheck = time.Time{}
+
+	expected[1].Plots[0].Value = 1.5
+
+	actual, err = c.GetPlots(context.Background(), query)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	if err := compareSeries(expected, actual); err != nil {
+		test.Log(err)
+		test.Fail()
+	}
+
+	if refreshRequests != 1 {
+		test.Logf("\nExpected 1 refresh request\nbut got  %d", refreshRequests)
+		test.Fail()
+	}
+
+	// Remove dataset file and check for its series to be no longer served
+	if err := os.Remove(filePath); err != nil {
+		test.Fatal(err)
+	}
+
+	c.(*FileConnector).lastCheck = time.Time{}
+
+	if _, err := c.GetPlots(context.Background(), query); err == nil {
+		test.Logf("\nExpected error for removed `load.shortterm' metric\nbut got  nil")
+		test.Fail()
+	}
+}
+
+func Test_FileGetPlotsStep(test *testing.T) {
+	dirPath, err := createTestFileDataset()
+	if err != nil {
+		test.Fatal(err)
+	}
+	defer os.RemoveAll(dirPath)
+
+	c, err := Connectors["file"]("file", map[string]interface{}{"path": dirPath})
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	runTestRefresh(c, "file")
+
+	query := &plot.Query{
+		StartTime: time.Unix(0, 0),
+		EndTime:   time.Unix(180, 0),
+		Sample:    1,
+		Series: []plot.QuerySeries{
+			{Name: "series0", Origin: "file", Source: "host1", Metric: "cpu.idle"},
+		},
+	}
+
+	expected := []*plot.Series{
+		{Name: "series0", Step: 180, Plots: []plot.Plot{
+			{Time: time.Unix(0, 0), Value: 91},
+			{Time: time.Unix(180, 0), Value: 95},
+		}},
+	}
+
+	actual, err := c.GetPlots(context.Background(), query)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	if err := compareSeries(expected, actual); err != nil {
+		test.Log(err)
+		test.Fail()
+	}
+}
+
+func Test_FileMalformedLines(test *testing.T) {
+	expected := []catalog.Record{
+		{Origin: "file", Source: "host1", Metric: "cpu.idle"},
+		{Origin: "file", Source: "host1", Metric: "load.shortterm"},
+		{Origin: "file", Source: "host3", Metric: "cpu.idle"},
+		{Origin: "file", Source: "host3", Metric: "load.shortterm"},
+	}
+
+	dirPath, err := ioutil.TempDir("", "facette")
+	if err != nil {
+		test.Fatal(err)
+	}
+	defer os.RemoveAll(dirPath)
+
+	if err := ioutil.WriteFile(path.Join(dirPath, "cpu.csv"), []byte(`source,metric,time,value
+host1,cpu.idle,0,90
+host2,cpu.idle,60
+host2,cpu.idle,abc,50
+host2,cpu.idle,"60,50
+`), 0644); err != nil {
+		test.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(path.Join(dirPath, "cpu.jsonl"), []byte(`{"source": "host3", "metric": "cpu.idle", `+
+		`"time": 0, "value": 10}`+"\n"), 0644); err != nil {
+		test.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(path.Join(dirPath, "load.jsonl"), []byte(`
+{"source": "host1", "metric": "load.shortterm", "time": 60, "value": 0.5}
+{"source": "host2", "metric": "load.shortterm", "time": 60
+{"source": "host2", "metric": "load.shortterm", "time": true, "value": 0.5}
+{"source": "host3", "metric": "load.shortterm", "time": 60, "value": 0.7}
+`), 0644); err != nil {
+		test.Fatal(err)
+	}
+
+	c, err := Connectors["file"]("file", map[string]interface{}{"path": dirPath})
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	actual := runTestRefresh(c, "file")
+
+	sort.Sort(fileTestRecordList(actual))
+
+	if !reflect.DeepEqual(expected, actual) {
+		test.Logf("\nExpected %s\nbut got  %s", expected, actual)
+		test.Fail()
+	}
+}
+
+func createTestFileDataset() (string, error) {
+	dirPath, err := ioutil.TempDir("", "facette")
+	if err != nil {
+		return "", err
+	}
+
+	if err := ioutil.WriteFile(path.Join(dirPath, "cpu.csv"), []byte(`source,metric,time,value
+host1,cpu.idle,180,95
+host1,cpu.idle,0,90
+host1,cpu.idle,60,92
+host1,cpu.idle,120,
+host2,cpu.idle,0,50
+`), 0644); err != nil {
+		return "", err
+	}
+
+	if err := ioutil.WriteFile(path.Join(dirPath, "load.jsonl"), []byte(`
+{"source": "host1", "metric": "load.shortterm", "time": 60, "value": 0.5}
+{"source": "host1", "metric": "load.shortterm", "time": 240, "value": 0.7}
+`), 0644); err != nil {
+		return "", err
+	}
+
+	if err := ioutil.WriteFile(path.Join(dirPath, "README"), []byte("ignored"), 0644); err != nil {
+		return "", err
+	}
+
+	return dirPath, nil
+}
+
+type fileTestRecordList []catalog.Record
+
+func (l fileTestRecordList) Len() int {
+	return len(l)
+}
+
+func (l fileTestRecordList) Less(i, j int) bool {
+	if l[i].Source != l[j].Source {
+		return l[i].Source < l[j].Source
+	}
+
+	return l[i].Metric < l[j].Metric
+}
+
+func (l fileTestRecordList) Swap(i, j int) {
+	l[i], l[j] = l[j], l[i]
+}
@@ -3,6 +3,7 @@
 package connector
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -84,7 +85,7 @@ func (c *GraphiteConnector) GetName() string {
 }
 
 // GetPlots retrieves time series data from provider based on a query and a time interval.
-func (c *GraphiteConnector) GetPlots(query *plot.Query) ([]*plot.Series, error) {
+func (c *GraphiteConnector) GetPlots(ctx context.Context, query *plot.Query) ([]*plot.Series, error) {
 	var (
 		plots   []graphitePlot
 		results []*plot.Series
@@ -103,7 +104,7 @@ func (c *GraphiteConnector) GetPlots(query *plot.Query) ([]*plot.Series, error)
 	// Request data from backend
 	client := utils.NewHTTPClient(c.timeout, c.insecureTLS)
 
-	r, err := http.NewRequest("GET", strings.TrimSuffix(c.url, "/")+graphiteURLRender+"?"+queryURL, nil)
+	r, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(c.url, "/")+graphiteURLRender+"?"+queryURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("graphite[%s]: unable to set up HTTP request: %s", c.name, err)
 	}
@@ -140,13 +141,13 @@ func (c *GraphiteConnector) GetPlots(query *plot.Query) ([]*plot.Series, error)
 }
 
 // Refresh triggers a full connector data update.
-func (c *GraphiteConnector) Refresh(originName string, outputChan chan<- *catalog.Record) error {
+func (c *GraphiteConnector) Refresh(ctx context.Context, originName string, outputChan chan<- *catalog.Record) error {
 	var series []string
 
 	// Request metrics from backend
 	client := utils.NewHTTPClient(c.timeout, c.insecureTLS)
 
-	r, err := http.NewRequest("GET", strings.TrimSuffix(c.url, "/")+graphiteURLMetrics, nil)
+	r, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(c.url, "/")+graphiteURLMetrics, nil)
 	if err != nil {
 		return fmt.Errorf("graphite[%s]: unable to set up HTTP request: %s", c.name, err)
 	}
@@ -3,6 +3,7 @@
 package connector
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strconv"
@@ -95,7 +96,7 @@ func (connector *InfluxDBConnector) GetName() string {
 }
 
 // GetPlots retrieves time series data from provider based on a query and a time interval.
-func (connector *InfluxDBConnector) GetPlots(query *plot.Query) ([]*plot.Series, error) {
+func (connector *InfluxDBConnector) GetPlots(ctx context.Context, query *plot.Query) ([]*plot.Series, error) {
 	l := len(query.Series)
 	if l == 0 {
 		return nil, fmt.Errorf("influxdb[%s]: requested series list is empty", connector.name)
@@ -116,7 +117,7 @@ func (connector *InfluxDBConnector) GetPlots(query *plot.Query) ([]*plot.Series,
 		query.EndTime.Unix(),
 	)
 
-	q, err := connector.client.Query(influxdbQuery, "s")
+	q, err := connector.query(ctx, influxdbQuery, influxdb.Second)
 	if err != nil {
 		return nil, fmt.Errorf("influxdb[%s]: unable to perform query: %s", connector.name, err)
 	}
@@ -164,8 +165,10 @@ func (connector *InfluxDBConnector) GetPlots(query *plot.Query) ([]*plot.Series,
 }
 
 // Refresh triggers a full connector data update.
-func (connector *InfluxDBConnector) Refresh(originName string, outputChan chan<- *catalog.Record) error {
-	seriesList, err := connector.client.Query("select * from /.*/ limit 1")
+func (connector *InfluxDBConnector) Refresh(ctx context.Context, originName string,
+	outputChan chan<- *catalog.Record) error {
+
+	seriesList, err := connector.query(ctx, "select * from /.*/ limit 1")
 	if err != nil {
 		return fmt.Errorf("influxdb[%s]: unable to fetch series list: %s", connector.name, err)
 	}
@@ -223,3 +226,29 @@ func (connector *InfluxDBConnector) Refresh(originName string, outputChan chan<-
 
 	return nil
 }
+
+func (connector *InfluxDBConnector) query(ctx context.Context, query string,
+	precision ...influxdb.TimePrecision) ([]*influxdb.Series, error) {
+
+	type queryResult struct {
+		series []*influxdb.Series
+		err    error
+	}
+
+	// The client does not support cancellation: run query in the background and stop waiting for its result as soon
+	// as the context is done.
+	resultChan := make(chan queryResult, 1)
+
+	go func() {
+		series, err := connector.client.Query(query, precision...)
+		resultChan <- queryResult{series, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+
+	case result := <-resultChan:
+		return result.series, result.err
+	}
+}
@@ -0,0 +1,437 @@
+// +build influxdb1
+
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/facette/facette/pkg/catalog"
+	"github.com/facette/facette/pkg/config"
+	"github.com/facette/facette/pkg/logger"
+	"github.com/facette/facette/pkg/plot"
+	"github.com/facette/facette/pkg/utils"
+)
+
+const (
+	influxdb1DefaultURL        string = "http://localhost:8086"
+	influxdb1DefaultTimeout    int    = 10
+	influxdb1DefaultSourceTag  string = "host"
+	influxdb1DefaultAggregator string = "mean"
+	influxdb1URLQuery          string = "/query"
+)
+
+type influxdb1Response struct {
+	Results []influxdb1Result `json:"results"`
+	Error   string            `json:"error"`
+}
+
+type influxdb1Result struct {
+	StatementID int               `json:"statement_id"`
+	Series      []influxdb1Series `json:"series"`
+	Error       string            `json:"error"`
+}
+
+type influxdb1Series struct {
+	Name    string          `json:"name"`
+	Columns []string        `json:"columns"`
+	Values  [][]interface{} `json:"values"`
+}
+
+type influxdb1SeriesEntry struct {
+	measurement string
+	field       string
+	aggregator  string
+}
+
+// InfluxDB1Connector represents the main structure of the InfluxDB 1.x connector.
+type InfluxDB1Connector struct {
+	name              string
+	url               string
+	timeout           int
+	insecureTLS       bool
+	username          string
+	password          string
+	database          string
+	retentionPolicy   string
+	sourceTag         string
+	defaultAggregator string
+	aggregators       []metricAggregator
+	series            map[string]map[string]influxdb1SeriesEntry
+}
+
+func init() {
+	Connectors["influxdb1"] = func(name string, settings map[string]interface{}) (Connector, error) {
+		var (
+			aggregators interface{}
+			err         error
+		)
+
+		c := &InfluxDB1Connector{
+			name:   name,
+			series: make(map[string]map[string]influxdb1SeriesEntry),
+		}
+
+		if c.url, err = config.GetString(settings, "url", false); err != nil {
+			return nil, err
+		}
+		if c.url == "" {
+			c.url = influxdb1DefaultURL
+		}
+
+		if c.timeout, err = config.GetInt(settings, "timeout", false); err != nil {
+			return nil, err
+		}
+		if c.timeout <= 0 {
+			c.timeout = influxdb1DefaultTimeout
+		}
+
+		if c.insecureTLS, err = config.GetBool(settings, "allow_insecure_tls", false); err != nil {
+			return nil, err
+		}
+
+		if c.username, err = config.GetString(settings, "username", false); err != nil {
+			return nil, err
+		}
+
+		if c.password, err = config.GetString(settings, "password", false); err != nil {
+			return nil, err
+		}
+
+		if c.database, err = config.GetString(settings, "database", true); err != nil {
+			return nil, err
+		}
+
+		if c.retentionPolicy, err = config.GetString(settings, "retention_policy", false); err != nil {
+			return nil, err
+		}
+
+		if c.sourceTag, err = config.GetString(settings, "source_tag", false); err != nil {
+			return nil, err
+		}
+		if c.sourceTag == "" {
+			c.sourceTag = influxdb1DefaultSourceTag
+		}
+
+		if c.defaultAggregator, err = config.GetString(settings, "default_aggregator", false); err != nil {
+			return nil, err
+		}
+		if c.defaultAggregator == "" {
+			c.defaultAggregator = influxdb1DefaultAggregator
+		}
+
+		if aggregators, err = config.GetJsonArray(settings, "aggregators", false); err != nil {
+			return nil, err
+		}
+
+		c.aggregators = compileAggregatorPatterns(aggregators, "influxdb1", c.name)
+
+		return c, nil
+	}
+}
+
+// GetName returns the name of the current connector.
+func (c *InfluxDB1Connector) GetName() string {
+	return c.name
+}
+
+// GetPlots retrieves time series data from provider based on a query and a time interval.
+func (c *InfluxDB1Connector) GetPlots(ctx context.Context, query *plot.Query) ([]*plot.Series, error) {
+	var results []*plot.Series
+
+	if len(query.Series) == 0 {
+		return nil, fmt.Errorf("influxdb1[%s]: requested series list is empty", c.name)
+	}
+
+	step := getPlotStep(query.StartTime, query.EndTime, query.Sample)
+
+	// Build one statement per requested series, sent together in a single request
+	statements := make([]string, len(query.Series))
+
+	for i, s := range query.Series {
+		if _, ok := c.series[s.Source]; !ok {
+			return nil, fmt.Errorf("influxdb1[%s]: unknown source `%s'", c.name, s.Source)
+		} else if _, ok := c.series[s.Source][s.Metric]; !ok {
+			return nil, fmt.Errorf("influxdb1[%s]: unknown metric `%s' for source `%s'", c.name, s.Metric, s.Source)
+		}
+
+		entry := c.series[s.Source][s.Metric]
+
+		statements[i] = fmt.Sprintf(
+			"SELECT %s(%s) FROM %s WHERE %s = %s AND time >= %ds AND time <= %ds GROUP BY time(%ds) fill(null)",
+			entry.aggregator,
+			influxdb1QuoteIdent(entry.field),
+			c.measurementClause(entry.measurement),
+			influxdb1QuoteIdent(c.sourceTag),
+			influxdb1QuoteString(s.Source),
+			query.StartTime.Unix(),
+			query.EndTime.Unix(),
+			step,
+		)
+	}
+
+	response, err := c.query(ctx, strings.Join(statements, "; "))
+	if err != nil {
+		return nil, err
+	}
+
+	for i, s := range query.Series {
+		series := &plot.Series{
+			Name: s.Name,
+			Step: step,
+		}
+
+		if i < len(response.Results) {
+			for _, rs := range response.Results[i].Series {
+				for _, value := range rs.Values {
+					p, err := influxdb1ParsePlot(value)
+					if err != nil {
+						return nil, fmt.Errorf("influxdb1[%s]: unable to parse plot value: %s", c.name, err)
+					}
+
+					series.Plots = append(series.Plots, p)
+				}
+			}
+		}
+
+		results = append(results, series)
+	}
+
+	return results, nil
+}
+
+// Refresh triggers a full connector data update.
+func (c *InfluxDB1Connector) Refresh(ctx context.Context, originName string, outputChan chan<- *catalog.Record) error {
+	// Request measurements list from backend
+	response, err := c.query(ctx, "SHOW MEASUREMENTS")
+	if err != nil {
+		return err
+	}
+
+	measurements := influxdb1GetColumnValues(response.Results, "name")
+
+	for _, measurement := range measurements {
+		// Request numeric fields and source tag values of the current measurement
+		response, err := c.query(ctx, fmt.Sprintf("SHOW FIELD KEYS FROM %s; SHOW TAG VALUES FROM %s WITH KEY = %s",
+			c.measurementClause(measurement), c.measurementClause(measurement), influxdb1QuoteIdent(c.sourceTag)))
+		if err != nil {
+			return err
+		}
+
+		if len(response.Results) != 2 {
+			return fmt.Errorf("influxdb1[%s]: got %d results, expected 2", c.name, len(response.Results))
+		}
+
+		fields := make([]string, 0)
+
+		for _, rs := range response.Results[0].Series {
+			keyIndex, typeIndex := influxdb1GetColumnIndex(rs, "fieldKey"), influxdb1GetColumnIndex(rs, "fieldType")
+			if keyIndex == -1 {
+				continue
+			}
+
+			for _, value := range rs.Values {
+				if typeIndex != -1 {
+					if fieldType, _ := value[typeIndex].(string); fieldType != "float" && fieldType != "integer" {
+						continue
+					}
+				}
+
+				if field, ok := value[keyIndex].(string); ok {
+					fields = append(fields, field)
+				}
+			}
+		}
+
+		sources := influxdb1GetColumnValues(response.Results[1:], "value")
+
+		if len(sources) == 0 {
+			logger.Log(logger.LevelInfo, "connector", "influxdb1[%s]: measurement `%s' has no `%s' tag, ignoring",
+				c.name, measurement, c.sourceTag)
+			continue
+		}
+
+		for _, field := range fields {
+			metricName := measurement + "." + field
+
+			aggregator := c.defaultAggregator
+			if hook, ok := matchAggregatorPattern(c.aggregators, metricName).(string); ok {
+				aggregator = hook
+				logger.Log(logger.LevelInfo, "connector", "influxdb1[%s]: `%s' applied to `%s'", c.name, aggregator,
+					metricName)
+			}
+
+			for _, sourceName := range sources {
+				if _, ok := c.series[sourceName]; !ok {
+					c.series[sourceName] = make(map[string]influxdb1SeriesEntry)
+				}
+
+				c.series[sourceName][metricName] = influxdb1SeriesEntry{
+					measurement: measurement,
+					field:       field,
+					aggregator:  aggregator,
+				}
+
+				outputChan <- &catalog.Record{
+					Origin:    originName,
+					Source:    sourceName,
+					Metric:    metricName,
+					Connector: c,
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *InfluxDB1Connector) measurementClause(measurement string) string {
+	if c.retentionPolicy != "" {
+		return influxdb1QuoteIdent(c.retentionPolicy) + "." + influxdb1QuoteIdent(measurement)
+	}
+
+	return influxdb1QuoteIdent(measurement)
+}
+
+func (c *InfluxDB1Connector) query(ctx context.Context, statement string) (*influxdb1Response, error) {
+	var response influxdb1Response
+
+	params := url.Values{}
+	params.Set("db", c.database)
+	params.Set("epoch", "s")
+	params.Set("q", statement)
+
+	queryURL := strings.TrimSuffix(c.url, "/") + influxdb1URLQuery + "?" + params.Encode()
+
+	logger.Log(logger.LevelDebug, "connector", "influxdb1[%s]: executing query: %s", c.name, statement)
+
+	r, err := http.NewRequestWithContext(ctx, "GET", queryURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("influxdb1[%s]: unable to set up HTTP request: %s", c.name, err)
+	}
+
+	r.Header.Add("User-Agent", "Facette")
+	r.Header.Add("X-Requested-With", "InfluxDB1Connector")
+
+	if c.username != "" {
+		r.SetBasicAuth(c.username, c.password)
+	}
+
+	rsp, err := utils.NewHTTPClient(c.timeout, c.insecureTLS).Do(r)
+	if err != nil {
+		return nil, fmt.Errorf("influxdb1[%s]: unable to perform HTTP request: %s", c.name, err)
+	}
+	defer rsp.Body.Close()
+
+	data, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("influxdb1[%s]: unable to read HTTP response body: %s", c.name, err)
+	}
+
+	if err = influxdb1CheckBackendResponse(rsp); err != nil {
+		// Try to report backend error message if any
+		if json.Unmarshal(data, &response) == nil && response.Error != "" {
+			return nil, fmt.Errorf("influxdb1[%s]: backend returned an error: %s", c.name, response.Error)
+		}
+
+		return nil, fmt.Errorf("influxdb1[%s]: invalid HTTP backend response: %s", c.name, err)
+	}
+
+	if err = json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("influxdb1[%s]: unable to unmarshal JSON data: %s", c.name, err)
+	}
+
+	if response.Error != "" {
+		return nil, fmt.Errorf("influxdb1[%s]: backend returned an error: %s", c.name, response.Error)
+	}
+
+	for _, result := range response.Results {
+		if result.Error != "" {
+			return nil, fmt.Errorf("influxdb1[%s]: backend returned an error: %s", c.name, result.Error)
+		}
+	}
+
+	return &response, nil
+}
+
+func influxdb1CheckBackendResponse(r *http.Response) error {
+	if r.StatusCode != 200 {
+		return fmt.Errorf("got HTTP status code %d, expected 200", r.StatusCode)
+	}
+
+	if utils.HTTPGetContentType(r) != "application/json" {
+		return fmt.Errorf("got HTTP content type `%s', expected `application/json'", r.Header["Content-Type"])
+	}
+
+	return nil
+}
+
+func influxdb1GetColumnIndex(series influxdb1Series, column string) int {
+	for i, name := range series.Columns {
+		if name == column {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func influxdb1GetColumnValues(results []influxdb1Result, column string) []string {
+	values := make([]string, 0)
+
+	for _, result := range results {
+		for _, series := range result.Series {
+			index := influxdb1GetColumnIndex(series, column)
+			if index == -1 {
+				continue
+			}
+
+			for _, row := range series.Values {
+				if value, ok := row[index].(string); ok {
+					values = append(values, value)
+				}
+			}
+		}
+	}
+
+	return values
+}
+
+func influxdb1ParsePlot(value []interface{}) (plot.Plot, error) {
+	if len(value) < 2 {
+		return plot.Plot{}, fmt.Errorf("invalid plot `%v'", value)
+	}
+
+	timestamp, ok := value[0].(float64)
+	if !ok {
+		return plot.Plot{}, fmt.Errorf("invalid timestamp `%v'", value[0])
+	}
+
+	result := math.NaN()
+
+	if value[1] != nil {
+		if result, ok = value[1].(float64); !ok {
+			return plot.Plot{}, fmt.Errorf("invalid value `%v'", value[1])
+		}
+	}
+
+	return plot.Plot{
+		Time:  time.Unix(int64(timestamp), 0),
+		Value: plot.Value(result),
+	}, nil
+}
+
+func influxdb1QuoteIdent(input string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(input) + `"`
+}
+
+func influxdb1QuoteString(input string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(input) + "'"
+}
@@ -0,0 +1,116 @@
+// +build influxdb1
+
+package connector
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/facette/facette/pkg/catalog"
+	"github.com/facette/facette/pkg/plot"
+)
+
+func Test_InfluxDB1Refresh(test *testing.T) {
+	expected := []catalog.Record{
+		{Origin: "influxdb", Source: "host1", Metric: "cpu.usage_idle"},
+		{Origin: "influxdb", Source: "host2", Metric: "cpu.usage_idle"},
+		{Origin: "influxdb", Source: "host1", Metric: "cpu.usage_user"},
+		{Origin: "influxdb", Source: "host2", Metric: "cpu.usage_user"},
+	}
+
+	backend := httptest.NewServer(http.HandlerFunc(influxdb1TestHandler))
+	defer backend.Close()
+
+	c, err := Connectors["influxdb1"]("influxdb", map[string]interface{}{"url": backend.URL, "database": "telegraf"})
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	actual := runTestRefresh(c, "influxdb")
+
+	if !reflect.DeepEqual(expected, actual) {
+		test.Logf("\nExpected %s\nbut got  %s", expected, actual)
+		test.Fail()
+	}
+}
+
+func Test_InfluxDB1GetPlots(test *testing.T) {
+	expected := []*plot.Series{
+		{Name: "series0", Step: 60, Plots: []plot.Plot{
+			{Time: time.Unix(0, 0), Value: 97.5},
+			{Time: time.Unix(60, 0), Value: plot.Value(math.NaN())},
+			{Time: time.Unix(120, 0), Value: 95},
+		}},
+	}
+
+	backend := httptest.NewServer(http.HandlerFunc(influxdb1TestHandler))
+	defer backend.Close()
+
+	c, err := Connectors["influxdb1"]("influxdb", map[string]interface{}{"url": backend.URL, "database": "telegraf"})
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	runTestRefresh(c, "influxdb")
+
+	actual, err := c.GetPlots(context.Background(), &plot.Query{
+		StartTime: time.Unix(0, 0),
+		EndTime:   time.Unix(180, 0),
+		Sample:    3,
+		Series: []plot.QuerySeries{
+			{Name: "series0", Origin: "influxdb", Source: "host1", Metric: "cpu.usage_idle"},
+		},
+	})
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	if err := compareSeries(expected, actual); err != nil {
+		test.Log(err)
+		test.Fail()
+	}
+}
+
+func influxdb1TestHandler(writer http.ResponseWriter, request *http.Request) {
+	var data string
+
+	if request.URL.Path != "/query" || request.FormValue("db") != "telegraf" {
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	switch request.FormValue("q") {
+	case "SHOW MEASUREMENTS":
+		data = `[{"statement_id":0,"series":[{"name":"measurements","columns":["name"],
+			"values":[["cpu"],["syslog"]]}]}]`
+
+	case `SHOW FIELD KEYS FROM "cpu"; SHOW TAG VALUES FROM "cpu" WITH KEY = "host"`:
+		data = `[{"statement_id":0,"series":[{"name":"cpu","columns":["fieldKey","fieldType"],
+			"values":[["usage_idle","float"],["usage_user","float"],["cpu_name","string"]]}]},
+			{"statement_id":1,"series":[{"name":"cpu","columns":["key","value"],
+			"values":[["host","host1"],["host","host2"]]}]}]`
+
+	case `SHOW FIELD KEYS FROM "syslog"; SHOW TAG VALUES FROM "syslog" WITH KEY = "host"`:
+		data = `[{"statement_id":0,"series":[{"name":"syslog","columns":["fieldKey","fieldType"],
+			"values":[["message","string"]]}]},{"statement_id":1}]`
+
+	case `SELECT mean("usage_idle") FROM "cpu" WHERE "host" = 'host1' AND time >= 0s AND time <= 180s ` +
+		`GROUP BY time(60s) fill(null)`:
+		data = `[{"statement_id":0,"series":[{"name":"cpu","columns":["time","mean"],
+			"values":[[0,97.5],[60,null],[120,95]]}]}]`
+
+	default:
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(http.StatusBadRequest)
+		writer.Write([]byte(`{"error":"unexpected query"}`))
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Write([]byte(`{"results":` + data + `}`))
+}
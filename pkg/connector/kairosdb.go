@@ -4,6 +4,7 @@ package connector
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -35,12 +36,6 @@ type kairosdbSeriesEntry struct {
 	aggregator interface{}
 }
 
-type metricAggregator struct {
-	pattern string
-	re      *regexp.Regexp
-	hook    interface{}
-}
-
 type metricQueryEntry struct {
 	Name        string              `json:"name"`
 	Tags        map[string][]string `json:"tags"`
@@ -135,7 +130,7 @@ func init() {
 			return nil, err
 		}
 
-		c.aggregators = compileAggregatorPatterns(aggregators, c.name)
+		c.aggregators = compileAggregatorPatterns(aggregators, "kairosdb", c.name)
 
 		if c.startAbsolute > 0 && c.startRelative != nil {
 			return nil, fmt.Errorf("kairosdb[%s]: start_absolute/start_relative are mutually exclusive", c.name)
@@ -181,7 +176,7 @@ func (c *KairosdbConnector) GetName() string {
 }
 
 // GetPlots retrieves time series data from provider based on a query and a time interval.
-func (c *KairosdbConnector) GetPlots(query *plot.Query) ([]*plot.Series, error) {
+func (c *KairosdbConnector) GetPlots(ctx context.Context, query *plot.Query) ([]*plot.Series, error) {
 	var (
 		jsonResponse map[string][]metricQueryResponse
 		results      []*plot.Series
@@ -202,7 +197,8 @@ func (c *KairosdbConnector) GetPlots(query *plot.Query) ([]*plot.Series, error)
 		strings.TrimSuffix(c.url, "/")+kairosdbURLQueryMetric,
 		string(jsonQuery))
 
-	r, err := http.NewRequest("POST", strings.TrimSuffix(c.url, "/")+kairosdbURLQueryMetric, bytes.NewBuffer(jsonQuery))
+	r, err := http.NewRequestWithContext(ctx, "POST", strings.TrimSuffix(c.url, "/")+kairosdbURLQueryMetric,
+		bytes.NewBuffer(jsonQuery))
 	if err != nil {
 		return nil, fmt.Errorf("kairosdb[%s]: unable to set up HTTP request: %s", c.name, err)
 	}
@@ -238,7 +234,7 @@ func (c *KairosdbConnector) GetPlots(query *plot.Query) ([]*plot.Series, error)
 }
 
 // Refresh triggers a full connector data update.
-func (c *KairosdbConnector) Refresh(originName string, outputChan chan<- *catalog.Record) error {
+func (c *KairosdbConnector) Refresh(ctx context.Context, originName string, outputChan chan<- *catalog.Record) error {
 	var (
 		jsonMetrics map[string][]string
 		jsonQuery   map[string][]map[string][]struct {
@@ -249,7 +245,7 @@ func (c *KairosdbConnector) Refresh(originName string, outputChan chan<- *catalo
 
 	client := utils.NewHTTPClient(c.timeout, c.insecureTLS)
 
-	r, err := http.NewRequest("GET", strings.TrimSuffix(c.url, "/")+kairosdbURLMetricNames, nil)
+	r, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(c.url, "/")+kairosdbURLMetricNames, nil)
 	if err != nil {
 		return fmt.Errorf("kairosdb[%s]: unable to set up HTTP request: %s", c.name, err)
 	}
@@ -304,7 +300,8 @@ func (c *KairosdbConnector) Refresh(originName string, outputChan chan<- *catalo
 	logger.Log(logger.LevelDebug, "connector", "kairosdb[%s]: API Call to %s: %s", c.name,
 		strings.TrimSuffix(c.url, "/")+kairosdbURLMetricTags, string(jsonData))
 
-	r, err = http.NewRequest("POST", strings.TrimSuffix(c.url, "/")+kairosdbURLMetricTags, bytes.NewBuffer(jsonData))
+	r, err = http.NewRequestWithContext(ctx, "POST", strings.TrimSuffix(c.url, "/")+kairosdbURLMetricTags,
+		bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Errorf("kairosdb[%s]: unable to set up HTTP request: %s", c.name, err)
 	}
@@ -526,49 +523,3 @@ func kairosdbGetVersion(c *KairosdbConnector) (string, [3]int, error) {
 
 	return "", array, fmt.Errorf("can't fetch KairosDB version")
 }
-
-func matchAggregatorPattern(aggregators []metricAggregator, metric string) interface{} {
-	if aggregators == nil {
-		return nil
-	}
-
-	for _, a := range aggregators {
-		if a.re.MatchString(metric) {
-			return a.hook
-		}
-	}
-
-	return nil
-}
-
-func compileAggregatorPatterns(aggregators interface{}, connector string) []metricAggregator {
-	var (
-		re  *regexp.Regexp
-		err error
-	)
-
-	if aggregators == nil {
-		return nil
-	}
-
-	list := aggregators.([]interface{})
-	out := make([]metricAggregator, 0)
-
-	for _, a := range list {
-		aggregator := a.(map[string]interface{})
-
-		if re, err = regexp.Compile(aggregator["metric"].(string)); err != nil {
-			logger.Log(logger.LevelWarning, "connector", "kairosdb[%s]: can't compile `%s', skipping", connector,
-				aggregator["metric"].(string))
-			continue
-		}
-
-		out = append(out, metricAggregator{
-			pattern: aggregator["metric"].(string),
-			re:      re,
-			hook:    aggregator["aggregator"],
-		})
-	}
-
-	return out
-}
@@ -0,0 +1,395 @@
+// +build opentsdb
+
+package connector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/facette/facette/pkg/catalog"
+	"github.com/facette/facette/pkg/config"
+	"github.com/facette/facette/pkg/logger"
+	"github.com/facette/facette/pkg/plot"
+	"github.com/facette/facette/pkg/utils"
+)
+
+const (
+	opentsdbDefaultTimeout    int    = 10
+	opentsdbDefaultLimit      int    = 10000
+	opentsdbDefaultAggregator string = "avg"
+	opentsdbURLSuggest        string = "/api/suggest"
+	opentsdbURLLookup         string = "/api/search/lookup"
+	opentsdbURLQuery          string = "/api/query"
+)
+
+type opentsdbSeriesEntry struct {
+	metric     string
+	tag        string
+	source     string
+	aggregator string
+}
+
+type opentsdbLookupResponse struct {
+	Results []struct {
+		Metric string            `json:"metric"`
+		Tags   map[string]string `json:"tags"`
+	} `json:"results"`
+}
+
+type opentsdbQuery struct {
+	Start   int64                `json:"start"`
+	End     int64                `json:"end"`
+	Queries []opentsdbQueryEntry `json:"queries"`
+}
+
+type opentsdbQueryEntry struct {
+	Aggregator string            `json:"aggregator"`
+	Metric     string            `json:"metric"`
+	Downsample string            `json:"downsample,omitempty"`
+	Tags       map[string]string `json:"tags"`
+}
+
+type opentsdbQueryResult struct {
+	Metric string             `json:"metric"`
+	Tags   map[string]string  `json:"tags"`
+	Dps    map[string]float64 `json:"dps"`
+}
+
+// OpenTSDBConnector represents the main structure of the OpenTSDB connector.
+type OpenTSDBConnector struct {
+	name              string
+	url               string
+	timeout           int
+	insecureTLS       bool
+	limit             int
+	sourceTags        []string
+	defaultAggregator string
+	aggregators       []metricAggregator
+	series            map[string]map[string]opentsdbSeriesEntry
+}
+
+func init() {
+	Connectors["opentsdb"] = func(name string, settings map[string]interface{}) (Connector, error) {
+		var (
+			aggregators interface{}
+			err         error
+		)
+
+		c := &OpenTSDBConnector{
+			name:   name,
+			series: make(map[string]map[string]opentsdbSeriesEntry),
+		}
+
+		if c.url, err = config.GetString(settings, "url", true); err != nil {
+			return nil, err
+		}
+
+		if c.timeout, err = config.GetInt(settings, "timeout", false); err != nil {
+			return nil, err
+		}
+		if c.timeout <= 0 {
+			c.timeout = opentsdbDefaultTimeout
+		}
+
+		if c.insecureTLS, err = config.GetBool(settings, "allow_insecure_tls", false); err != nil {
+			return nil, err
+		}
+
+		if c.limit, err = config.GetInt(settings, "limit", false); err != nil {
+			return nil, err
+		}
+		if c.limit <= 0 {
+			c.limit = opentsdbDefaultLimit
+		}
+
+		if c.sourceTags, err = config.GetStringSlice(settings, "source_tags", false); err != nil {
+			return nil, err
+		}
+
+		if c.defaultAggregator, err = config.GetString(settings, "default_aggregator", false); err != nil {
+			return nil, err
+		}
+
+		if aggregators, err = config.GetJsonArray(settings, "aggregators", false); err != nil {
+			return nil, err
+		}
+
+		c.aggregators = compileAggregatorPatterns(aggregators, "opentsdb", c.name)
+
+		// Enforce defaults
+		if c.sourceTags == nil {
+			c.sourceTags = []string{"host"}
+		}
+
+		if c.defaultAggregator == "" {
+			c.defaultAggregator = opentsdbDefaultAggregator
+		}
+
+		return c, nil
+	}
+}
+
+// GetName returns the name of the current connector.
+func (c *OpenTSDBConnector) GetName() string {
+	return c.name
+}
+
+// GetPlots retrieves time series data from provider based on a query and a time interval.
+func (c *OpenTSDBConnector) GetPlots(ctx context.Context, query *plot.Query) ([]*plot.Series, error) {
+	var (
+		queryResults []opentsdbQueryResult
+		results      []*plot.Series
+	)
+
+	if len(query.Series) == 0 {
+		return nil, fmt.Errorf("opentsdb[%s]: requested series list is empty", c.name)
+	}
+
+	jsonQuery, err := opentsdbBuildJSONQuery(query, c.series)
+	if err != nil {
+		return nil, fmt.Errorf("opentsdb[%s]: unable to build or marshal JSON query: %s", c.name, err)
+	}
+
+	client := utils.NewHTTPClient(c.timeout, c.insecureTLS)
+
+	logger.Log(logger.LevelDebug, "connector", "opentsdb[%s]: API Call to %s: %s", c.name,
+		strings.TrimSuffix(c.url, "/")+opentsdbURLQuery, string(jsonQuery))
+
+	r, err := http.NewRequestWithContext(ctx, "POST", strings.TrimSuffix(c.url, "/")+opentsdbURLQuery,
+		bytes.NewBuffer(jsonQuery))
+	if err != nil {
+		return nil, fmt.Errorf("opentsdb[%s]: unable to set up HTTP request: %s", c.name, err)
+	}
+
+	r.Header.Add("User-Agent", "Facette")
+	r.Header.Add("X-Requested-With", "OpenTSDBConnector")
+	r.Header.Set("Content-Type", "application/json")
+
+	if err = c.doRequest(client, r, &queryResults); err != nil {
+		return nil, err
+	}
+
+	if results, err = opentsdbExtractPlots(query, c.series, queryResults); err != nil {
+		return nil, fmt.Errorf("opentsdb[%s]: unable to extract plot values from backend response: %s", c.name, err)
+	}
+
+	return results, nil
+}
+
+// Refresh triggers a full connector data update.
+func (c *OpenTSDBConnector) Refresh(ctx context.Context, originName string, outputChan chan<- *catalog.Record) error {
+	var metrics []string
+
+	client := utils.NewHTTPClient(c.timeout, c.insecureTLS)
+
+	// Request metric names from backend
+	params := url.Values{}
+	params.Set("type", "metrics")
+	params.Set("max", strconv.Itoa(c.limit))
+
+	r, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(c.url, "/")+opentsdbURLSuggest+"?"+
+		params.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("opentsdb[%s]: unable to set up HTTP request: %s", c.name, err)
+	}
+
+	r.Header.Add("User-Agent", "Facette")
+	r.Header.Add("X-Requested-With", "OpenTSDBConnector")
+
+	if err = c.doRequest(client, r, &metrics); err != nil {
+		return err
+	}
+
+	for _, metricName := range metrics {
+		var lookup opentsdbLookupResponse
+
+		aggregator := c.defaultAggregator
+		if hook, ok := matchAggregatorPattern(c.aggregators, metricName).(string); ok {
+			aggregator = hook
+
+			logger.Log(logger.LevelInfo, "connector", "opentsdb[%s]: `%s' applied to `%s'", c.name, aggregator,
+				metricName)
+		}
+
+		// Look up time series of the current metric to retrieve their sources
+		params := url.Values{}
+		params.Set("m", metricName)
+		params.Set("limit", strconv.Itoa(c.limit))
+
+		r, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(c.url, "/")+opentsdbURLLookup+"?"+
+			params.Encode(), nil)
+		if err != nil {
+			return fmt.Errorf("opentsdb[%s]: unable to set up HTTP request: %s", c.name, err)
+		}
+
+		r.Header.Add("User-Agent", "Facette")
+		r.Header.Add("X-Requested-With", "OpenTSDBConnector")
+
+		if err = c.doRequest(client, r, &lookup); err != nil {
+			return err
+		}
+
+		for _, result := range lookup.Results {
+			for _, t := range c.sourceTags {
+				sourceName, ok := result.Tags[t]
+				if !ok {
+					continue
+				}
+
+				if _, ok := c.series[sourceName]; !ok {
+					c.series[sourceName] = make(map[string]opentsdbSeriesEntry)
+				}
+
+				if _, ok := c.series[sourceName][metricName]; !ok {
+					c.series[sourceName][metricName] = opentsdbSeriesEntry{
+						tag:        t,
+						source:     sourceName,
+						metric:     metricName,
+						aggregator: aggregator,
+					}
+
+					outputChan <- &catalog.Record{
+						Origin:    originName,
+						Source:    sourceName,
+						Metric:    metricName,
+						Connector: c,
+					}
+				}
+
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *OpenTSDBConnector) doRequest(client *http.Client, r *http.Request, result interface{}) error {
+	rsp, err := client.Do(r)
+	if err != nil {
+		return fmt.Errorf("opentsdb[%s]: unable to perform HTTP request: %s", c.name, err)
+	}
+	defer rsp.Body.Close()
+
+	if err = opentsdbCheckBackendResponse(rsp); err != nil {
+		return fmt.Errorf("opentsdb[%s]: invalid HTTP backend response: %s", c.name, err)
+	}
+
+	data, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		return fmt.Errorf("opentsdb[%s]: unable to read HTTP response body: %s", c.name, err)
+	}
+
+	if err = json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("opentsdb[%s]: unable to unmarshal JSON data: %s", c.name, err)
+	}
+
+	return nil
+}
+
+func opentsdbBuildJSONQuery(query *plot.Query,
+	opentsdbSeries map[string]map[string]opentsdbSeriesEntry) ([]byte, error) {
+
+	q := opentsdbQuery{
+		Start: query.StartTime.Unix(),
+		End:   query.EndTime.Unix(),
+	}
+
+	step := getPlotStep(query.StartTime, query.EndTime, query.Sample)
+
+	for _, series := range query.Series {
+		entry, ok := opentsdbSeries[series.Source][series.Metric]
+		if !ok {
+			return nil, fmt.Errorf("unknown metric `%s' for source `%s'", series.Metric, series.Source)
+		}
+
+		q.Queries = append(q.Queries, opentsdbQueryEntry{
+			Aggregator: entry.aggregator,
+			Metric:     entry.metric,
+			Downsample: fmt.Sprintf("%ds-%s", step, entry.aggregator),
+			Tags:       map[string]string{entry.tag: entry.source},
+		})
+	}
+
+	return json.Marshal(q)
+}
+
+func opentsdbExtractPlots(query *plot.Query, opentsdbSeries map[string]map[string]opentsdbSeriesEntry,
+	queryResults []opentsdbQueryResult) ([]*plot.Series, error) {
+
+	step := getPlotStep(query.StartTime, query.EndTime, query.Sample)
+
+	// Return exactly one series per requested one, in query order, as the backend response neither follows the
+	// queries order nor contains entries for queries having no data
+	results := make([]*plot.Series, len(query.Series))
+
+	for i, s := range query.Series {
+		var result *opentsdbQueryResult
+
+		entry := opentsdbSeries[s.Source][s.Metric]
+
+		for j := range queryResults {
+			if queryResults[j].Metric != entry.metric || queryResults[j].Tags[entry.tag] != entry.source {
+				continue
+			}
+
+			if result != nil {
+				return nil, fmt.Errorf("ambiguity during plot target retrieval for `%s'", s.Name)
+			}
+
+			result = &queryResults[j]
+		}
+
+		results[i] = &plot.Series{
+			Name: s.Name,
+			Step: step,
+		}
+
+		if result == nil {
+			continue
+		}
+
+		// Sort data points as they are returned as an unordered JSON object
+		timestamps := make([]int, 0)
+		for key := range result.Dps {
+			timestamp, err := strconv.Atoi(key)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timestamp `%s'", key)
+			}
+
+			timestamps = append(timestamps, timestamp)
+		}
+
+		sort.Ints(timestamps)
+
+		for _, timestamp := range timestamps {
+			results[i].Plots = append(results[i].Plots, plot.Plot{
+				Time:  time.Unix(int64(timestamp), 0),
+				Value: plot.Value(result.Dps[strconv.Itoa(timestamp)]),
+			})
+		}
+	}
+
+	return results, nil
+}
+
+func opentsdbCheckBackendResponse(r *http.Response) error {
+	if r.StatusCode != 200 {
+		return fmt.Errorf("got HTTP status code %d, expected 200", r.StatusCode)
+	}
+
+	if utils.HTTPGetContentType(r) != "application/json" {
+		return fmt.Errorf("got HTTP content type `%s', expected `application/json'", r.Header["Content-Type"])
+	}
+
+	return nil
+}
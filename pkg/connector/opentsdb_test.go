@@ -0,0 +1,125 @@
+// +build opentsdb
+
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/facette/facette/pkg/catalog"
+	"github.com/facette/facette/pkg/plot"
+)
+
+func Test_OpenTSDBRefresh(test *testing.T) {
+	expected := []catalog.Record{
+		{Origin: "opentsdb", Source: "host1", Metric: "sys.cpu.user"},
+		{Origin: "opentsdb", Source: "host2", Metric: "sys.cpu.user"},
+		{Origin: "opentsdb", Source: "host1", Metric: "sys.load"},
+	}
+
+	backend := httptest.NewServer(http.HandlerFunc(opentsdbTestHandler))
+	defer backend.Close()
+
+	c, err := Connectors["opentsdb"]("opentsdb", map[string]interface{}{"url": backend.URL})
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	actual := runTestRefresh(c, "opentsdb")
+
+	if !reflect.DeepEqual(expected, actual) {
+		test.Logf("\nExpected %s\nbut got  %s", expected, actual)
+		test.Fail()
+	}
+}
+
+func Test_OpenTSDBGetPlots(test *testing.T) {
+	// Backend response is returned in reverse order, lacks an entry for `sys.load' and holds an unrequested one
+	expected := []*plot.Series{
+		{Name: "series0", Step: 60, Plots: []plot.Plot{
+			{Time: time.Unix(0, 0), Value: 0.5},
+			{Time: time.Unix(60, 0), Value: 1},
+			{Time: time.Unix(120, 0), Value: 1.25},
+		}},
+		{Name: "series1", Step: 60},
+		{Name: "series2", Step: 60, Plots: []plot.Plot{
+			{Time: time.Unix(0, 0), Value: 2},
+			{Time: time.Unix(60, 0), Value: 3},
+		}},
+	}
+
+	backend := httptest.NewServer(http.HandlerFunc(opentsdbTestHandler))
+	defer backend.Close()
+
+	c, err := Connectors["opentsdb"]("opentsdb", map[string]interface{}{"url": backend.URL})
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	runTestRefresh(c, "opentsdb")
+
+	actual, err := c.GetPlots(context.Background(), &plot.Query{
+		StartTime: time.Unix(0, 0),
+		EndTime:   time.Unix(180, 0),
+		Sample:    3,
+		Series: []plot.QuerySeries{
+			{Name: "series0", Origin: "opentsdb", Source: "host1", Metric: "sys.cpu.user"},
+			{Name: "series1", Origin: "opentsdb", Source: "host1", Metric: "sys.load"},
+			{Name: "series2", Origin: "opentsdb", Source: "host2", Metric: "sys.cpu.user"},
+		},
+	})
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	if err := compareSeries(expected, actual); err != nil {
+		test.Log(err)
+		test.Fail()
+	}
+}
+
+func opentsdbTestHandler(writer http.ResponseWriter, request *http.Request) {
+	var data string
+
+	switch request.URL.Path {
+	case "/api/suggest":
+		data = `["sys.cpu.user","sys.load"]`
+
+	case "/api/search/lookup":
+		switch request.FormValue("m") {
+		case "sys.cpu.user":
+			data = `{"results":[{"metric":"sys.cpu.user","tags":{"host":"host1"}},
+				{"metric":"sys.cpu.user","tags":{"host":"host2"}}]}`
+		case "sys.load":
+			data = `{"results":[{"metric":"sys.load","tags":{"host":"host1"}},
+				{"metric":"sys.load","tags":{"dc":"dc1"}}]}`
+		default:
+			data = `{"results":[]}`
+		}
+
+	case "/api/query":
+		var query opentsdbQuery
+
+		if err := json.NewDecoder(request.Body).Decode(&query); err != nil || len(query.Queries) != 3 ||
+			query.Queries[0].Downsample != "60s-avg" {
+			writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		data = `[{"metric":"sys.cpu.user","tags":{"host":"host3"},"dps":{"0":9}},
+			{"metric":"sys.cpu.user","tags":{"host":"host2"},"dps":{"60":3,"0":2}},
+			{"metric":"sys.cpu.user","tags":{"host":"host1"},"dps":{"120":1.25,"0":0.5,"60":1}}]`
+
+	default:
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Write([]byte(data))
+}
@@ -0,0 +1,331 @@
+// +build prometheus
+
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/facette/facette/pkg/catalog"
+	"github.com/facette/facette/pkg/config"
+	"github.com/facette/facette/pkg/logger"
+	"github.com/facette/facette/pkg/plot"
+	"github.com/facette/facette/pkg/utils"
+)
+
+const (
+	prometheusDefaultTimeout     int    = 10
+	prometheusDefaultMetricLabel string = "__name__"
+	prometheusURLLabelValues     string = "/api/v1/label/%s/values"
+	prometheusURLSeries          string = "/api/v1/series"
+	prometheusURLQueryRange      string = "/api/v1/query_range"
+)
+
+type prometheusResponse struct {
+	Status    string          `json:"status"`
+	Data      json.RawMessage `json:"data"`
+	ErrorType string          `json:"errorType"`
+	Error     string          `json:"error"`
+}
+
+type prometheusMatrixData struct {
+	ResultType string `json:"resultType"`
+	Result     []struct {
+		Metric map[string]string `json:"metric"`
+		Values [][2]interface{}  `json:"values"`
+	} `json:"result"`
+}
+
+// PrometheusConnector represents the main structure of the Prometheus connector.
+type PrometheusConnector struct {
+	name         string
+	url          string
+	timeout      int
+	insecureTLS  bool
+	sourceLabels []string
+	metricLabel  string
+	series       map[string]map[string]map[string]string
+}
+
+func init() {
+	Connectors["prometheus"] = func(name string, settings map[string]interface{}) (Connector, error) {
+		var err error
+
+		c := &PrometheusConnector{
+			name:   name,
+			series: make(map[string]map[string]map[string]string),
+		}
+
+		if c.url, err = config.GetString(settings, "url", true); err != nil {
+			return nil, err
+		}
+
+		if c.timeout, err = config.GetInt(settings, "timeout", false); err != nil {
+			return nil, err
+		}
+		if c.timeout <= 0 {
+			c.timeout = prometheusDefaultTimeout
+		}
+
+		if c.insecureTLS, err = config.GetBool(settings, "allow_insecure_tls", false); err != nil {
+			return nil, err
+		}
+
+		if c.sourceLabels, err = config.GetStringSlice(settings, "source_labels", false); err != nil {
+			return nil, err
+		}
+
+		if c.metricLabel, err = config.GetString(settings, "metric_label", false); err != nil {
+			return nil, err
+		}
+
+		// Enforce labels defaults
+		if c.sourceLabels == nil {
+			c.sourceLabels = []string{"instance"}
+		}
+
+		if c.metricLabel == "" {
+			c.metricLabel = prometheusDefaultMetricLabel
+		}
+
+		return c, nil
+	}
+}
+
+// GetName returns the name of the current connector.
+func (c *PrometheusConnector) GetName() string {
+	return c.name
+}
+
+// GetPlots retrieves time series data from provider based on a query and a time interval.
+func (c *PrometheusConnector) GetPlots(ctx context.Context, query *plot.Query) ([]*plot.Series, error) {
+	var results []*plot.Series
+
+	if len(query.Series) == 0 {
+		return nil, fmt.Errorf("prometheus[%s]: requested series list is empty", c.name)
+	}
+
+	step := getPlotStep(query.StartTime, query.EndTime, query.Sample)
+
+	client := utils.NewHTTPClient(c.timeout, c.insecureTLS)
+
+	for _, s := range query.Series {
+		var data prometheusMatrixData
+
+		if _, ok := c.series[s.Source]; !ok {
+			return nil, fmt.Errorf("prometheus[%s]: unknown source `%s'", c.name, s.Source)
+		} else if _, ok := c.series[s.Source][s.Metric]; !ok {
+			return nil, fmt.Errorf("prometheus[%s]: unknown metric `%s' for source `%s'", c.name, s.Metric, s.Source)
+		}
+
+		params := url.Values{}
+		params.Set("query", prometheusBuildSelector(c.series[s.Source][s.Metric]))
+		params.Set("start", strconv.FormatInt(query.StartTime.Unix(), 10))
+		params.Set("end", strconv.FormatInt(query.EndTime.Unix(), 10))
+		params.Set("step", strconv.Itoa(step))
+
+		if err := c.apiCall(ctx, client, prometheusURLQueryRange, params, &data); err != nil {
+			return nil, err
+		}
+
+		if data.ResultType != "matrix" {
+			return nil, fmt.Errorf("prometheus[%s]: got result type `%s', expected `matrix'", c.name,
+				data.ResultType)
+		}
+
+		series := &plot.Series{
+			Name: s.Name,
+			Step: step,
+		}
+
+		if len(data.Result) > 0 {
+			if len(data.Result) > 1 {
+				logger.Log(logger.LevelWarning, "connector", "prometheus[%s]: `%s' matches %d series, keeping first",
+					c.name, s.Metric, len(data.Result))
+			}
+
+			for _, value := range data.Result[0].Values {
+				p, err := prometheusParsePlot(value)
+				if err != nil {
+					return nil, fmt.Errorf("prometheus[%s]: unable to parse plot value: %s", c.name, err)
+				}
+
+				series.Plots = append(series.Plots, p)
+			}
+		}
+
+		results = append(results, series)
+	}
+
+	return results, nil
+}
+
+// Refresh triggers a full connector data update.
+func (c *PrometheusConnector) Refresh(ctx context.Context, originName string, outputChan chan<- *catalog.Record) error {
+	var metrics []string
+
+	client := utils.NewHTTPClient(c.timeout, c.insecureTLS)
+
+	// Request metric names from backend
+	if err := c.apiCall(ctx, client, fmt.Sprintf(prometheusURLLabelValues, url.QueryEscape(c.metricLabel)), nil,
+		&metrics); err != nil {
+		return err
+	}
+
+	for _, metricName := range metrics {
+		var series []map[string]string
+
+		// Request series matching the current metric name to retrieve their sources
+		params := url.Values{}
+		params.Set("match[]", prometheusBuildSelector(map[string]string{c.metricLabel: metricName}))
+
+		if err := c.apiCall(ctx, client, prometheusURLSeries, params, &series); err != nil {
+			return err
+		}
+
+		for _, labels := range series {
+			var sourceLabel, sourceName string
+
+			for _, label := range c.sourceLabels {
+				if value, ok := labels[label]; ok {
+					sourceLabel, sourceName = label, value
+					break
+				}
+			}
+
+			if sourceName == "" {
+				logger.Log(logger.LevelInfo, "connector", "prometheus[%s]: series `%s' has no source label, ignoring",
+					c.name, prometheusBuildSelector(labels))
+				continue
+			}
+
+			if _, ok := c.series[sourceName]; !ok {
+				c.series[sourceName] = make(map[string]map[string]string)
+			}
+
+			c.series[sourceName][metricName] = map[string]string{
+				c.metricLabel: metricName,
+				sourceLabel:   sourceName,
+			}
+
+			outputChan <- &catalog.Record{
+				Origin:    originName,
+				Source:    sourceName,
+				Metric:    metricName,
+				Connector: c,
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *PrometheusConnector) apiCall(ctx context.Context, client *http.Client, path string, params url.Values,
+	result interface{}) error {
+
+	var response prometheusResponse
+
+	apiURL := strings.TrimSuffix(c.url, "/") + path
+	if params != nil {
+		apiURL += "?" + params.Encode()
+	}
+
+	logger.Log(logger.LevelDebug, "connector", "prometheus[%s]: API Call to %s", c.name, apiURL)
+
+	r, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return fmt.Errorf("prometheus[%s]: unable to set up HTTP request: %s", c.name, err)
+	}
+
+	r.Header.Add("User-Agent", "Facette")
+	r.Header.Add("X-Requested-With", "PrometheusConnector")
+
+	rsp, err := client.Do(r)
+	if err != nil {
+		return fmt.Errorf("prometheus[%s]: unable to perform HTTP request: %s", c.name, err)
+	}
+	defer rsp.Body.Close()
+
+	if err = prometheusCheckBackendResponse(rsp); err != nil {
+		return fmt.Errorf("prometheus[%s]: invalid HTTP backend response: %s", c.name, err)
+	}
+
+	data, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		return fmt.Errorf("prometheus[%s]: unable to read HTTP response body: %s", c.name, err)
+	}
+
+	if err = json.Unmarshal(data, &response); err != nil {
+		return fmt.Errorf("prometheus[%s]: unable to unmarshal JSON data: %s", c.name, err)
+	}
+
+	if response.Status != "success" {
+		return fmt.Errorf("prometheus[%s]: backend returned an error: %s (%s)", c.name, response.Error,
+			response.ErrorType)
+	}
+
+	if err = json.Unmarshal(response.Data, result); err != nil {
+		return fmt.Errorf("prometheus[%s]: unable to unmarshal JSON data: %s", c.name, err)
+	}
+
+	return nil
+}
+
+func prometheusCheckBackendResponse(r *http.Response) error {
+	if r.StatusCode != 200 {
+		return fmt.Errorf("got HTTP status code %d, expected 200", r.StatusCode)
+	}
+
+	if utils.HTTPGetContentType(r) != "application/json" {
+		return fmt.Errorf("got HTTP content type `%s', expected `application/json'", r.Header["Content-Type"])
+	}
+
+	return nil
+}
+
+func prometheusBuildSelector(labels map[string]string) string {
+	keys := make([]string, 0)
+	for key := range labels {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	matchers := make([]string, len(keys))
+	for i, key := range keys {
+		matchers[i] = fmt.Sprintf("%s=%s", key, strconv.Quote(labels[key]))
+	}
+
+	return "{" + strings.Join(matchers, ",") + "}"
+}
+
+func prometheusParsePlot(value [2]interface{}) (plot.Plot, error) {
+	timestamp, ok := value[0].(float64)
+	if !ok {
+		return plot.Plot{}, fmt.Errorf("invalid timestamp `%v'", value[0])
+	}
+
+	valueString, ok := value[1].(string)
+	if !ok {
+		return plot.Plot{}, fmt.Errorf("invalid value `%v'", value[1])
+	}
+
+	result, err := strconv.ParseFloat(valueString, 64)
+	if err != nil {
+		result = math.NaN()
+	}
+
+	return plot.Plot{
+		Time:  time.Unix(int64(timestamp), 0),
+		Value: plot.Value(result),
+	}, nil
+}
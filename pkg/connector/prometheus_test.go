@@ -0,0 +1,158 @@
+// +build prometheus
+
+package connector
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/facette/facette/pkg/catalog"
+	"github.com/facette/facette/pkg/plot"
+)
+
+func Test_PrometheusRefresh(test *testing.T) {
+	expected := []catalog.Record{
+		{Origin: "prometheus", Source: "host1:9100", Metric: "node_load1"},
+		{Origin: "prometheus", Source: "host2:9100", Metric: "node_load1"},
+		{Origin: "prometheus", Source: "host1:9100", Metric: "node_load5"},
+	}
+
+	backend := httptest.NewServer(http.HandlerFunc(prometheusTestHandler))
+	defer backend.Close()
+
+	c, err := Connectors["prometheus"]("prometheus", map[string]interface{}{"url": backend.URL})
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	actual := runTestRefresh(c, "prometheus")
+
+	if !reflect.DeepEqual(expected, actual) {
+		test.Logf("\nExpected %s\nbut got  %s", expected, actual)
+		test.Fail()
+	}
+}
+
+func Test_PrometheusGetPlots(test *testing.T) {
+	expected := []*plot.Series{
+		{Name: "series0", Step: 60, Plots: []plot.Plot{
+			{Time: time.Unix(0, 0), Value: 0.5},
+			{Time: time.Unix(60, 0), Value: plot.Value(math.NaN())},
+			{Time: time.Unix(120, 0), Value: 1.25},
+		}},
+	}
+
+	backend := httptest.NewServer(http.HandlerFunc(prometheusTestHandler))
+	defer backend.Close()
+
+	c, err := Connectors["prometheus"]("prometheus", map[string]interface{}{"url": backend.URL})
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	runTestRefresh(c, "prometheus")
+
+	actual, err := c.GetPlots(context.Background(), &plot.Query{
+		StartTime: time.Unix(0, 0),
+		EndTime:   time.Unix(180, 0),
+		Sample:    3,
+		Series: []plot.QuerySeries{
+			{Name: "series0", Origin: "prometheus", Source: "host1:9100", Metric: "node_load1"},
+		},
+	})
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	if err := compareSeries(expected, actual); err != nil {
+		test.Log(err)
+		test.Fail()
+	}
+}
+
+func Test_PrometheusGetPlotsCancel(test *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+
+	backend := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path == "/api/v1/query_range" {
+			// Block until either the client gives up or the test ends
+			select {
+			case <-request.Context().Done():
+			case <-done:
+			}
+
+			return
+		}
+
+		prometheusTestHandler(writer, request)
+	}))
+	defer backend.Close()
+
+	c, err := Connectors["prometheus"]("prometheus", map[string]interface{}{"url": backend.URL})
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	runTestRefresh(c, "prometheus")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if _, err := c.GetPlots(ctx, &plot.Query{
+		StartTime: time.Unix(0, 0),
+		EndTime:   time.Unix(180, 0),
+		Sample:    3,
+		Series: []plot.QuerySeries{
+			{Name: "series0", Origin: "prometheus", Source: "host1:9100", Metric: "node_load1"},
+		},
+	}); err == nil {
+		test.Logf("\nExpected error\nbut got  nil")
+		test.Fail()
+	}
+}
+
+func prometheusTestHandler(writer http.ResponseWriter, request *http.Request) {
+	var data string
+
+	switch request.URL.Path {
+	case "/api/v1/label/__name__/values":
+		data = `["node_load1","node_load5"]`
+
+	case "/api/v1/series":
+		switch request.FormValue("match[]") {
+		case `{__name__="node_load1"}`:
+			data = `[{"__name__":"node_load1","instance":"host1:9100","job":"node"},
+				{"__name__":"node_load1","instance":"host2:9100","job":"node"}]`
+		case `{__name__="node_load5"}`:
+			data = `[{"__name__":"node_load5","instance":"host1:9100","job":"node"},
+				{"__name__":"node_load5","job":"node"}]`
+		default:
+			data = `[]`
+		}
+
+	case "/api/v1/query_range":
+		if request.FormValue("query") != `{__name__="node_load1",instance="host1:9100"}` ||
+			request.FormValue("step") != "60" {
+			writer.Header().Set("Content-Type", "application/json")
+			writer.WriteHeader(http.StatusBadRequest)
+			writer.Write([]byte(`{"status":"error","errorType":"bad_data","error":"unexpected query"}`))
+			return
+		}
+
+		data = `{"resultType":"matrix","result":[{"metric":{"__name__":"node_load1","instance":"host1:9100"},
+			"values":[[0,"0.5"],[60,"NaN"],[120,"1.25"]]}]}`
+
+	default:
+		writer.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Write([]byte(`{"status":"success","data":` + data + `}`))
+}
@@ -3,6 +3,7 @@
 package connector
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"regexp"
@@ -77,7 +78,7 @@ func (c *RRDConnector) GetName() string {
 }
 
 // GetPlots retrieves time series data from origin based on a query and a time interval.
-func (c *RRDConnector) GetPlots(query *plot.Query) ([]*plot.Series, error) {
+func (c *RRDConnector) GetPlots(ctx context.Context, query *plot.Query) ([]*plot.Series, error) {
 	var (
 		results []*plot.Series
 		xport   *rrd.Exporter
@@ -119,6 +120,10 @@ func (c *RRDConnector) GetPlots(query *plot.Query) ([]*plot.Series, error) {
 		step = query.EndTime.Sub(query.StartTime) / time.Duration(config.DefaultPlotSample)
 	}
 
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("rrd[%s]: %s", c.name, err)
+	}
+
 	data := rrd.XportResult{}
 
 	data, err := xport.Xport(query.StartTime, query.EndTime, step)
@@ -148,11 +153,16 @@ func (c *RRDConnector) GetPlots(query *plot.Query) ([]*plot.Series, error) {
 }
 
 // Refresh triggers a full connector data update.
-func (c *RRDConnector) Refresh(originName string, outputChan chan<- *catalog.Record) error {
+func (c *RRDConnector) Refresh(ctx context.Context, originName string, outputChan chan<- *catalog.Record) error {
 	// Search for files and parse their path for source/metric pairs
 	walkFunc := func(filePath string, fileInfo os.FileInfo, err error) error {
 		var sourceName, metricName string
 
+		// Stop walking if refresh has been cancelled
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
 		// Report errors
 		if err != nil {
 			logger.Log(logger.LevelWarning, "connector", "rrd[%s]: error while walking: %s", c.name, err)
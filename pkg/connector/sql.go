@@ -0,0 +1,328 @@
+// +build sql
+
+package connector
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strconv"
+	"text/template"
+	"time"
+
+	"github.com/facette/facette/pkg/catalog"
+	"github.com/facette/facette/pkg/config"
+	"github.com/facette/facette/pkg/logger"
+	"github.com/facette/facette/pkg/plot"
+
+	// Register supported database drivers
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+)
+
+// sqlPlotQueryData is the plot query template data: each value expands to a driver placeholder, the actual value
+// being bound as query argument so that it never becomes part of the SQL statement text.
+type sqlPlotQueryData struct {
+	numbered bool
+	source   string
+	metric   string
+	start    int64
+	end      int64
+	step     int
+	args     []interface{}
+}
+
+// Source binds the requested source name.
+func (d *sqlPlotQueryData) Source() string {
+	return d.bind(d.source)
+}
+
+// Metric binds the requested metric name.
+func (d *sqlPlotQueryData) Metric() string {
+	return d.bind(d.metric)
+}
+
+// Start binds the requested range start Unix timestamp.
+func (d *sqlPlotQueryData) Start() string {
+	return d.bind(d.start)
+}
+
+// End binds the requested range end Unix timestamp.
+func (d *sqlPlotQueryData) End() string {
+	return d.bind(d.end)
+}
+
+// Step binds the requested plots step in seconds.
+func (d *sqlPlotQueryData) Step() string {
+	return d.bind(d.step)
+}
+
+func (d *sqlPlotQueryData) bind(value interface{}) string {
+	d.args = append(d.args, value)
+
+	if d.numbered {
+		return "$" + strconv.Itoa(len(d.args))
+	}
+
+	return "?"
+}
+
+// SQLConnector represents the main structure of the SQL connector.
+type SQLConnector struct {
+	name         string
+	driver       string
+	dsn          string
+	catalogQuery string
+	plotQuery    *template.Template
+	numbered     bool
+	db           *sql.DB
+	series       map[string]map[string]bool
+}
+
+func init() {
+	Connectors["sql"] = func(name string, settings map[string]interface{}) (Connector, error) {
+		var (
+			plotQuery   string
+			placeholder string
+			err         error
+		)
+
+		c := &SQLConnector{
+			name:   name,
+			series: make(map[string]map[string]bool),
+		}
+
+		if c.driver, err = config.GetString(settings, "driver", true); err != nil {
+			return nil, err
+		}
+
+		if c.dsn, err = config.GetString(settings, "dsn", true); err != nil {
+			return nil, err
+		}
+
+		if c.catalogQuery, err = config.GetString(settings, "catalog_query", true); err != nil {
+			return nil, err
+		}
+
+		if plotQuery, err = config.GetString(settings, "plot_query", true); err != nil {
+			return nil, err
+		}
+
+		if placeholder, err = config.GetString(settings, "placeholder", false); err != nil {
+			return nil, err
+		}
+
+		switch placeholder {
+		case "":
+			c.numbered = c.driver == "postgres"
+
+		case "?":
+			c.numbered = false
+
+		case "$1":
+			c.numbered = true
+
+		default:
+			return nil, fmt.Errorf("sql[%s]: unsupported placeholder `%s'", c.name, placeholder)
+		}
+
+		if c.plotQuery, err = template.New("plot_query").Parse(plotQuery); err != nil {
+			return nil, fmt.Errorf("sql[%s]: unable to parse plot query template: %s", c.name, err)
+		}
+
+		if c.db, err = sql.Open(c.driver, c.dsn); err != nil {
+			return nil, fmt.Errorf("sql[%s]: unable to open database: %s", c.name, err)
+		}
+
+		return c, nil
+	}
+}
+
+// GetName returns the name of the current connector.
+func (c *SQLConnector) GetName() string {
+	return c.name
+}
+
+// Close closes the connector database handle.
+func (c *SQLConnector) Close() error {
+	return c.db.Close()
+}
+
+// GetPlots retrieves time series data from provider based on a query and a time interval.
+func (c *SQLConnector) GetPlots(ctx context.Context, query *plot.Query) ([]*plot.Series, error) {
+	var results []*plot.Series
+
+	if len(query.Series) == 0 {
+		return nil, fmt.Errorf("sql[%s]: requested series list is empty", c.name)
+	}
+
+	step := getPlotStep(query.StartTime, query.EndTime, query.Sample)
+
+	for _, s := range query.Series {
+		if !c.series[s.Source][s.Metric] {
+			return nil, fmt.Errorf("sql[%s]: unknown metric `%s' for source `%s'", c.name, s.Metric, s.Source)
+		}
+
+		buf := bytes.NewBuffer(nil)
+
+		data := &sqlPlotQueryData{
+			numbered: c.numbered,
+			source:   s.Source,
+			metric:   s.Metric,
+			start:    query.StartTime.Unix(),
+			end:      query.EndTime.Unix(),
+			step:     step,
+		}
+
+		if err := c.plotQuery.Execute(buf, data); err != nil {
+			return nil, fmt.Errorf("sql[%s]: unable to execute plot query template: %s", c.name, err)
+		}
+
+		logger.Log(logger.LevelDebug, "connector", "sql[%s]: executing query: %s %v", c.name, buf.String(), data.args)
+
+		series, err := c.queryPlots(ctx, buf.String(), data.args)
+		if err != nil {
+			return nil, fmt.Errorf("sql[%s]: %s", c.name, err)
+		}
+
+		series.Name = s.Name
+		series.Step = step
+
+		results = append(results, series)
+	}
+
+	return results, nil
+}
+
+// Refresh triggers a full connector data update.
+func (c *SQLConnector) Refresh(ctx context.Context, originName string, outputChan chan<- *catalog.Record) error {
+	rows, err := c.db.QueryContext(ctx, c.catalogQuery)
+	if err != nil {
+		return fmt.Errorf("sql[%s]: unable to execute catalog query: %s", c.name, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sourceName, metricName string
+
+		if err := rows.Scan(&sourceName, &metricName); err != nil {
+			return fmt.Errorf("sql[%s]: unable to scan catalog row: %s", c.name, err)
+		}
+
+		if _, ok := c.series[sourceName]; !ok {
+			c.series[sourceName] = make(map[string]bool)
+		}
+
+		c.series[sourceName][metricName] = true
+
+		outputChan <- &catalog.Record{
+			Origin:    originName,
+			Source:    sourceName,
+			Metric:    metricName,
+			Connector: c,
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("sql[%s]: unable to fetch catalog rows: %s", c.name, err)
+	}
+
+	return nil
+}
+
+func (c *SQLConnector) queryPlots(ctx context.Context, query string, args []interface{}) (*plot.Series, error) {
+	rows, err := c.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute plot query: %s", err)
+	}
+	defer rows.Close()
+
+	series := &plot.Series{}
+
+	for rows.Next() {
+		var timeValue, value interface{}
+
+		if err := rows.Scan(&timeValue, &value); err != nil {
+			return nil, fmt.Errorf("unable to scan plot row: %s", err)
+		}
+
+		t, err := sqlParseTime(timeValue)
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := sqlParseValue(value)
+		if err != nil {
+			return nil, err
+		}
+
+		series.Plots = append(series.Plots, plot.Plot{Time: t, Value: v})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to fetch plot rows: %s", err)
+	}
+
+	return series, nil
+}
+
+func sqlParseTime(input interface{}) (time.Time, error) {
+	switch value := input.(type) {
+	case time.Time:
+		return value, nil
+
+	case int64:
+		return time.Unix(value, 0), nil
+
+	case float64:
+		return time.Unix(int64(value), 0), nil
+
+	case []byte:
+		return sqlParseTime(string(value))
+
+	case string:
+		if timestamp, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return time.Unix(timestamp, 0), nil
+		}
+
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"} {
+			if t, err := time.Parse(layout, value); err == nil {
+				return t, nil
+			}
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time value `%v'", input)
+}
+
+func sqlParseValue(input interface{}) (plot.Value, error) {
+	switch value := input.(type) {
+	case nil:
+		return plot.Value(math.NaN()), nil
+
+	case int64:
+		return plot.Value(value), nil
+
+	case float64:
+		return plot.Value(value), nil
+
+	case bool:
+		if value {
+			return 1, nil
+		}
+
+		return 0, nil
+
+	case []byte:
+		return sqlParseValue(string(value))
+
+	case string:
+		if result, err := strconv.ParseFloat(value, 64); err == nil {
+			return plot.Value(result), nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid plot value `%v'", input)
+}
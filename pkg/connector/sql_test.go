@@ -0,0 +1,214 @@
+// +build sql
+
+package connector
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/facette/facette/pkg/catalog"
+	"github.com/facette/facette/pkg/plot"
+
+	// Register embedded in-memory database driver
+	_ "github.com/proullon/ramsql/driver"
+)
+
+func Test_SQLRefresh(test *testing.T) {
+	expected := []catalog.Record{
+		{Origin: "kpi", Source: "shop", Metric: "orders.count"},
+		{Origin: "kpi", Source: "shop", Metric: "orders.amount"},
+		{Origin: "kpi", Source: "x' OR '1'='1", Metric: "orders.count"},
+	}
+
+	db := sqlTestDatabase(test, "refresh")
+	defer db.Close()
+
+	c, err := Connectors["sql"]("kpi", sqlTestSettings("refresh"))
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	actual := runTestRefresh(c, "kpi")
+
+	if !reflect.DeepEqual(expected, actual) {
+		test.Logf("\nExpected %s\nbut got  %s", expected, actual)
+		test.Fail()
+	}
+}
+
+func Test_SQLGetPlots(test *testing.T) {
+	// Source names are bound as query arguments: the quote in the third series name must neither break the
+	// statement nor match other sources rows
+	expected := []*plot.Series{
+		{Name: "series0", Step: 60, Plots: []plot.Plot{
+			{Time: time.Unix(0, 0), Value: 12},
+			{Time: time.Unix(60, 0), Value: plot.Value(math.NaN())},
+			{Time: time.Unix(120, 0), Value: 3.5},
+		}},
+		{Name: "series1", Step: 60, Plots: []plot.Plot{
+			{Time: time.Unix(60, 0), Value: 250},
+		}},
+		{Name: "series2", Step: 60, Plots: []plot.Plot{
+			{Time: time.Unix(120, 0), Value: 1},
+		}},
+	}
+
+	db := sqlTestDatabase(test, "plots")
+	defer db.Close()
+
+	c, err := Connectors["sql"]("kpi", sqlTestSettings("plots"))
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	runTestRefresh(c, "kpi")
+
+	actual, err := c.GetPlots(context.Background(), &plot.Query{
+		StartTime: time.Unix(0, 0),
+		EndTime:   time.Unix(180, 0),
+		Sample:    3,
+		Series: []plot.QuerySeries{
+			{Name: "series0", Origin: "kpi", Source: "shop", Metric: "orders.count"},
+			{Name: "series1", Origin: "kpi", Source: "shop", Metric: "orders.amount"},
+			{Name: "series2", Origin: "kpi", Source: "x' OR '1'='1", Metric: "orders.count"},
+		},
+	})
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	if err := compareSeries(expected, actual); err != nil {
+		test.Log(err)
+		test.Fail()
+	}
+}
+
+func Test_SQLPlotQueryData(test *testing.T) {
+	for _, entry := range []struct {
+		numbered bool
+		expected string
+	}{
+		{false, "WHERE s = ? AND m = ? AND t BETWEEN ? AND ? STEP ?"},
+		{true, "WHERE s = $1 AND m = $2 AND t BETWEEN $3 AND $4 STEP $5"},
+	} {
+		c, err := Connectors["sql"]("kpi", map[string]interface{}{
+			"driver":        "ramsql",
+			"dsn":           "data",
+			"catalog_query": "SELECT source, metric FROM catalog",
+			"plot_query": "WHERE s = {{ .Source }} AND m = {{ .Metric }} AND t BETWEEN {{ .Start }} AND {{ .End }} " +
+				"STEP {{ .Step }}",
+		})
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		data := &sqlPlotQueryData{numbered: entry.numbered, source: "x'", metric: "y", start: 0, end: 180, step: 60}
+
+		actual := bytes.NewBuffer(nil)
+		if err := c.(*SQLConnector).plotQuery.Execute(actual, data); err != nil {
+			test.Fatal(err)
+		}
+
+		if actual.String() != entry.expected {
+			test.Logf("\nExpected %q\nbut got  %q", entry.expected, actual.String())
+			test.Fail()
+		}
+
+		if expected := []interface{}{"x'", "y", int64(0), int64(180), 60}; !reflect.DeepEqual(expected, data.args) {
+			test.Logf("\nExpected %v\nbut got  %v", expected, data.args)
+			test.Fail()
+		}
+	}
+}
+
+func Test_SQLDrivers(test *testing.T) {
+	drivers := make(map[string]bool)
+	for _, name := range sql.Drivers() {
+		drivers[name] = true
+	}
+
+	for _, name := range []string{"mysql", "postgres"} {
+		if !drivers[name] {
+			test.Logf("\nExpected `%s' driver to be registered", name)
+			test.Fail()
+		}
+	}
+}
+
+func Test_SQLClose(test *testing.T) {
+	db := sqlTestDatabase(test, "close")
+	defer db.Close()
+
+	c, err := Connectors["sql"]("kpi", sqlTestSettings("close"))
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	if err := c.(io.Closer).Close(); err != nil {
+		test.Fatal(err)
+	}
+
+	if err := c.Refresh(context.Background(), "kpi", make(chan *catalog.Record)); err == nil {
+		test.Logf("\nExpected refresh to fail on closed connector")
+		test.Fail()
+	}
+}
+
+func sqlTestSettings(dsn string) map[string]interface{} {
+	return map[string]interface{}{
+		"driver":        "ramsql",
+		"dsn":           dsn,
+		"placeholder":   "$1",
+		"catalog_query": "SELECT source, metric FROM catalog",
+		"plot_query": "SELECT ts, value FROM plots WHERE source = {{ .Source }} AND metric = {{ .Metric }} " +
+			"AND ts >= {{ .Start }} AND ts <= {{ .End }} ORDER BY ts",
+	}
+}
+
+func sqlTestDatabase(test *testing.T, dsn string) *sql.DB {
+	db, err := sql.Open("ramsql", dsn)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	for _, query := range []string{
+		"CREATE TABLE catalog (source TEXT, metric TEXT)",
+		"CREATE TABLE plots (source TEXT, metric TEXT, ts INT, value FLOAT)",
+	} {
+		if _, err := db.Exec(query); err != nil {
+			test.Fatal(err)
+		}
+	}
+
+	for _, row := range [][]interface{}{
+		{"shop", "orders.count"},
+		{"shop", "orders.amount"},
+		{"x' OR '1'='1", "orders.count"},
+	} {
+		if _, err := db.Exec("INSERT INTO catalog (source, metric) VALUES ($1, $2)", row...); err != nil {
+			test.Fatal(err)
+		}
+	}
+
+	for _, row := range [][]interface{}{
+		{"shop", "orders.count", 0, 12.0},
+		{"shop", "orders.count", 60, nil},
+		{"shop", "orders.count", 120, 3.5},
+		{"shop", "orders.count", 240, 8.0},
+		{"shop", "orders.amount", 60, 250.0},
+		{"x' OR '1'='1", "orders.count", 120, 1.0},
+	} {
+		if _, err := db.Exec("INSERT INTO plots (source, metric, ts, value) VALUES ($1, $2, $3, $4)",
+			row...); err != nil {
+			test.Fatal(err)
+		}
+	}
+
+	return db
+}
@@ -0,0 +1,376 @@
+// +build synthetic
+
+package connector
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/facette/facette/pkg/catalog"
+	"github.com/facette/facette/pkg/config"
+	"github.com/facette/facette/pkg/plot"
+)
+
+const (
+	syntheticDefaultStep      int     = 60
+	syntheticDefaultPeriod    int     = 3600
+	syntheticDefaultAmplitude float64 = 1
+	syntheticDefaultRate      float64 = 1
+	syntheticDefaultGapSize   int     = 1
+	syntheticWalkOctaves      int     = 8
+)
+
+const (
+	_ = iota
+	syntheticTypeSine
+	syntheticTypeSawtooth
+	syntheticTypeRandomWalk
+	syntheticTypeStep
+	syntheticTypeCounter
+)
+
+var syntheticTypes = map[string]int{
+	"sine":        syntheticTypeSine,
+	"sawtooth":    syntheticTypeSawtooth,
+	"random_walk": syntheticTypeRandomWalk,
+	"step":        syntheticTypeStep,
+	"counter":     syntheticTypeCounter,
+}
+
+type syntheticMetric struct {
+	kind      int
+	period    int64
+	amplitude float64
+	offset    float64
+	rate      float64
+	noise     float64
+	gaps      float64
+	gapSize   int64
+}
+
+// SyntheticConnector represents the main structure of the synthetic data connector.
+type SyntheticConnector struct {
+	name    string
+	seed    int64
+	step    int
+	sources []string
+	metrics map[string]syntheticMetric
+}
+
+func init() {
+	Connectors["synthetic"] = func(name string, settings map[string]interface{}) (Connector, error) {
+		var (
+			seed    int
+			metrics map[string]interface{}
+			err     error
+		)
+
+		c := &SyntheticConnector{
+			name:    name,
+			metrics: make(map[string]syntheticMetric),
+		}
+
+		if seed, err = config.GetInt(settings, "seed", false); err != nil {
+			return nil, err
+		}
+		c.seed = int64(seed)
+
+		if c.step, err = config.GetInt(settings, "step", false); err != nil {
+			return nil, err
+		}
+		if c.step <= 0 {
+			c.step = syntheticDefaultStep
+		}
+
+		if c.sources, err = config.GetStringSlice(settings, "sources", true); err != nil {
+			return nil, err
+		}
+
+		if metrics, err = config.GetStringMap(settings, "metrics", true); err != nil {
+			return nil, err
+		}
+
+		for metricName, metricSettings := range metrics {
+			settings, ok := metricSettings.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("synthetic[%s]: invalid settings for metric `%s'", c.name, metricName)
+			}
+
+			if c.metrics[metricName], err = syntheticParseMetric(settings); err != nil {
+				return nil, fmt.Errorf("synthetic[%s]: invalid settings for metric `%s': %s", c.name, metricName, err)
+			}
+		}
+
+		return c, nil
+	}
+}
+
+// GetName returns the name of the current connector.
+func (c *SyntheticConnector) GetName() string {
+	return c.name
+}
+
+// GetPlots retrieves time series data from provider based on a query and a time interval.
+func (c *SyntheticConnector) GetPlots(ctx context.Context, query *plot.Query) ([]*plot.Series, error) {
+	var results []*plot.Series
+
+	if len(query.Series) == 0 {
+		return nil, fmt.Errorf("synthetic[%s]: requested series list is empty", c.name)
+	}
+
+	// Use the step matching the requested sample, rounded up to a multiple of the generation resolution
+	step := int64(getPlotStep(query.StartTime, query.EndTime, query.Sample))
+	step = (step + int64(c.step) - 1) / int64(c.step) * int64(c.step)
+
+	for _, s := range query.Series {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("synthetic[%s]: %s", c.name, err)
+		}
+
+		if !c.hasSource(s.Source) {
+			return nil, fmt.Errorf("synthetic[%s]: unknown source `%s'", c.name, s.Source)
+		}
+
+		metric, ok := c.metrics[s.Metric]
+		if !ok {
+			return nil, fmt.Errorf("synthetic[%s]: unknown metric `%s' for source `%s'", c.name, s.Metric, s.Source)
+		}
+
+		series := syntheticGenerate(metric, c.seriesKey(s.Source, s.Metric), query.StartTime, query.EndTime,
+			int64(c.step), step)
+		series.Name = s.Name
+
+		results = append(results, series)
+	}
+
+	return results, nil
+}
+
+// Refresh triggers a full connector data update.
+func (c *SyntheticConnector) Refresh(ctx context.Context, originName string, outputChan chan<- *catalog.Record) error {
+	metrics := make([]string, 0)
+	for metricName := range c.metrics {
+		metrics = append(metrics, metricName)
+	}
+
+	sort.Strings(metrics)
+
+	for _, sourceName := range c.sources {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("synthetic[%s]: %s", c.name, err)
+		}
+
+		for _, metricName := range metrics {
+			outputChan <- &catalog.Record{
+				Origin:    originName,
+				Source:    sourceName,
+				Metric:    metricName,
+				Connector: c,
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *SyntheticConnector) hasSource(name string) bool {
+	for _, sourceName := range c.sources {
+		if sourceName == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *SyntheticConnector) seriesKey(sourceName, metricName string) uint64 {
+	// Derive a series-specific key from the seed so that each source/metric pair gets its own values
+	hash := fnv.New64a()
+	fmt.Fprintf(hash, "%d\x1e%s\x1e%s", c.seed, sourceName, metricName)
+
+	return hash.Sum64()
+}
+
+func syntheticParseMetric(settings map[string]interface{}) (syntheticMetric, error) {
+	var (
+		kind    string
+		period  int
+		gapSize int
+		err     error
+	)
+
+	metric := syntheticMetric{}
+
+	if kind, err = config.GetString(settings, "type", true); err != nil {
+		return metric, err
+	}
+
+	if metric.kind = syntheticTypes[kind]; metric.kind == 0 {
+		return metric, fmt.Errorf("unsupported type `%s'", kind)
+	}
+
+	if period, err = config.GetInt(settings, "period", false); err != nil {
+		return metric, err
+	}
+	if period <= 0 {
+		period = syntheticDefaultPeriod
+	}
+	metric.period = int64(period)
+
+	if metric.amplitude, err = config.GetFloat(settings, "amplitude", false); err != nil {
+		return metric, err
+	}
+	if metric.amplitude == 0 {
+		metric.amplitude = syntheticDefaultAmplitude
+	}
+
+	if metric.offset, err = config.GetFloat(settings, "offset", false); err != nil {
+		return metric, err
+	}
+
+	if metric.rate, err = config.GetFloat(settings, "rate", false); err != nil {
+		return metric, err
+	}
+	if metric.rate == 0 {
+		metric.rate = syntheticDefaultRate
+	}
+
+	if metric.noise, err = config.GetFloat(settings, "noise", false); err != nil {
+		return metric, err
+	}
+
+	if metric.gaps, err = config.GetFloat(settings, "gaps", false); err != nil {
+		return metric, err
+	} else if metric.gaps < 0 || metric.gaps > 1 {
+		return metric, fmt.Errorf("gaps ratio must be between 0 and 1")
+	}
+
+	if gapSize, err = config.GetInt(settings, "gap_size", false); err != nil {
+		return metric, err
+	}
+	if gapSize <= 0 {
+		gapSize = syntheticDefaultGapSize
+	}
+	metric.gapSize = int64(gapSize)
+
+	return metric, nil
+}
+
+// syntheticGenerate generates the series plots over a time range. Values are computed on the resolution grid, then
+// picked every step (a multiple of the resolution), so that they only depend on their timestamp.
+func syntheticGenerate(metric syntheticMetric, key uint64, startTime, endTime time.Time,
+	resolution, step int64) *plot.Series {
+
+	var counterWindow, counterIndex int64 = -1, 0
+
+	series := &plot.Series{Step: int(step)}
+
+	// Align first point on the step boundary following the start time
+	from := startTime.Unix()
+	if from%step != 0 {
+		from += step - from%step
+	}
+
+	// Per-series phase shift so that sources sharing a metric definition are not identical
+	phase := int64(syntheticRandom(key, 0) * float64(metric.period))
+	if phase < 0 {
+		phase += metric.period
+	}
+
+	counter := metric.offset
+
+	for ts := from; ts <= endTime.Unix(); ts += step {
+		var value float64
+
+		index := ts / resolution
+
+		switch metric.kind {
+		case syntheticTypeSine:
+			value = metric.offset + metric.amplitude*math.Sin(2*math.Pi*float64((ts+phase)%metric.period)/
+				float64(metric.period))
+
+		case syntheticTypeSawtooth:
+			value = metric.offset + metric.amplitude*float64((ts+phase)%metric.period)/float64(metric.period)
+
+		case syntheticTypeRandomWalk:
+			value = metric.offset + metric.amplitude*syntheticWalk(key, index)
+
+		case syntheticTypeStep:
+			value = metric.offset + metric.amplitude*syntheticRandom(key, (ts+phase)/metric.period)
+
+		case syntheticTypeCounter:
+			// Counters increase by a jittered rate on each resolution step and are reset at the start of each period
+			window := (ts + phase) / metric.period
+
+			if window != counterWindow {
+				counter = metric.offset
+				counterIndex = (window*metric.period-phase+resolution-1)/resolution - 1
+				counterWindow = window
+			}
+
+			for counterIndex < index {
+				counterIndex++
+				counter += syntheticCounterIncrement(metric, key, counterIndex, resolution)
+			}
+
+			value = counter
+		}
+
+		if metric.noise != 0 {
+			value += metric.noise * syntheticRandom(key^0x6e6f697365, index)
+		}
+
+		if metric.gaps > 0 && (syntheticRandom(key^0x67617073, index/metric.gapSize)+1)/2 < metric.gaps {
+			value = math.NaN()
+		}
+
+		series.Plots = append(series.Plots, plot.Plot{
+			Time:  time.Unix(ts, 0),
+			Value: plot.Value(value),
+		})
+	}
+
+	return series
+}
+
+func syntheticCounterIncrement(metric syntheticMetric, key uint64, index, resolution int64) float64 {
+	return metric.rate * float64(resolution) * (1 + syntheticRandom(key^0x636f756e74, index)/2)
+}
+
+func syntheticWalk(key uint64, index int64) float64 {
+	var value float64
+
+	// Sum interpolated value noise over several octaves, amplitude growing with the square root of the scale to
+	// mimic a random walk while keeping each point computable independently of the requested range.
+	for octave := 0; octave < syntheticWalkOctaves; octave++ {
+		scale := int64(1) << uint(octave)
+
+		base := index / scale
+		if index < 0 && index%scale != 0 {
+			base--
+		}
+
+		ratio := float64(index-base*scale) / float64(scale)
+
+		a := syntheticRandom(key+uint64(octave), base)
+		b := syntheticRandom(key+uint64(octave), base+1)
+
+		value += (a + (b-a)*ratio) * math.Sqrt(float64(scale))
+	}
+
+	return value
+}
+
+func syntheticRandom(key uint64, index int64) float64 {
+	// SplitMix64 finalizer, returning a deterministic value in [-1, 1)
+	x := key ^ uint64(index)*0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	x = x ^ (x >> 31)
+
+	return float64(x>>11)/float64(1<<52) - 1
+}
@@ -0,0 +1,249 @@
+// +build synthetic
+
+package connector
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/facette/facette/pkg/catalog"
+	"github.com/facette/facette/pkg/plot"
+)
+
+func Test_SyntheticRefresh(test *testing.T) {
+	expected := []catalog.Record{
+		{Origin: "synthetic", Source: "host1", Metric: "cpu.idle"},
+		{Origin: "synthetic", Source: "host1", Metric: "net.packets"},
+		{Origin: "synthetic", Source: "host2", Metric: "cpu.idle"},
+		{Origin: "synthetic", Source: "host2", Metric: "net.packets"},
+	}
+
+	c, err := Connectors["synthetic"]("synthetic", syntheticTestSettings(42))
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	actual := runTestRefresh(c, "synthetic")
+
+	if !reflect.DeepEqual(expected, actual) {
+		test.Logf("\nExpected %s\nbut got  %s", expected, actual)
+		test.Fail()
+	}
+}
+
+func Test_SyntheticGetPlots(test *testing.T) {
+	c, err := Connectors["synthetic"]("synthetic", syntheticTestSettings(42))
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	query := &plot.Query{
+		StartTime: time.Unix(3600, 0),
+		EndTime:   time.Unix(10800, 0),
+		Series: []plot.QuerySeries{
+			{Name: "series0", Source: "host1", Metric: "cpu.idle"},
+			{Name: "series1", Source: "host2", Metric: "cpu.idle"},
+			{Name: "series2", Source: "host1", Metric: "net.packets"},
+		},
+	}
+
+	series, err := c.GetPlots(context.Background(), query)
+	if err != nil {
+		test.Fatal(err)
+	} else if len(series) != 3 {
+		test.Fatalf("\nExpected 3 series\nbut got  %d", len(series))
+	}
+
+	// Check for series length and values range
+	for _, s := range series {
+		if s.Step != 60 || len(s.Plots) != 121 {
+			test.Logf("\nExpected step 60 and 121 plots for `%s'\nbut got  step %d and %d plots", s.Name, s.Step,
+				len(s.Plots))
+			test.Fail()
+		}
+	}
+
+	for _, p := range series[0].Plots {
+		if !p.Value.IsNaN() && (p.Value < 40 || p.Value > 60) {
+			test.Logf("\nExpected values between 40 and 60\nbut got  %g", p.Value)
+			test.Fail()
+			break
+		}
+	}
+
+	// Check that sources sharing a metric definition get different values
+	if err := compareSeries(series[0:1], []*plot.Series{{Name: "series0", Step: 60,
+		Plots: series[1].Plots}}); err == nil {
+		test.Log("\nExpected different series for distinct sources\nbut got identical ones")
+		test.Fail()
+	}
+
+	// Check for counter resets
+	resets := 0
+	for i := 1; i < len(series[2].Plots); i++ {
+		if series[2].Plots[i].Value < series[2].Plots[i-1].Value {
+			resets++
+		}
+	}
+
+	if resets != 2 {
+		test.Logf("\nExpected 2 counter resets\nbut got  %d", resets)
+		test.Fail()
+	}
+
+	// Check for gaps
+	gaps := 0
+	for _, p := range series[0].Plots {
+		if p.Value.IsNaN() {
+			gaps++
+		}
+	}
+
+	if gaps == 0 || gaps == len(series[0].Plots) {
+		test.Logf("\nExpected some gaps\nbut got  %d on %d plots", gaps, len(series[0].Plots))
+		test.Fail()
+	}
+}
+
+func Test_SyntheticDeterministic(test *testing.T) {
+	for _, kind := range []string{"sine", "sawtooth", "random_walk", "step", "counter"} {
+		settings := map[string]interface{}{
+			"seed":    1234.0,
+			"sources": []interface{}{"host1"},
+			"metrics": map[string]interface{}{
+				"metric": map[string]interface{}{"type": kind, "period": 1800.0, "noise": 0.1, "gaps": 0.1},
+			},
+		}
+
+		c1, err := Connectors["synthetic"]("synthetic", settings)
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		c2, err := Connectors["synthetic"]("synthetic", settings)
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		// Fetch overlapping ranges from distinct connector instances and compare the common plots
+		s1, err := c1.GetPlots(context.Background(), &plot.Query{
+			StartTime: time.Unix(1000020, 0),
+			EndTime:   time.Unix(1007220, 0),
+			Series:    []plot.QuerySeries{{Name: "series0", Source: "host1", Metric: "metric"}},
+		})
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		s2, err := c2.GetPlots(context.Background(), &plot.Query{
+			StartTime: time.Unix(1003620, 0),
+			EndTime:   time.Unix(1010820, 0),
+			Series:    []plot.QuerySeries{{Name: "series0", Source: "host1", Metric: "metric"}},
+		})
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		expected := &plot.Series{Name: "series0", Step: 60, Plots: s1[0].Plots[60:]}
+		actual := &plot.Series{Name: "series0", Step: 60, Plots: s2[0].Plots[:61]}
+
+		if err := compareSeries([]*plot.Series{expected}, []*plot.Series{actual}); err != nil {
+			test.Logf("%s: %s", kind, err)
+			test.Fail()
+		}
+
+		// Fetch an overlapping range with a coarser sample: plots sharing a timestamp must have the same value
+		s4, err := c2.GetPlots(context.Background(), &plot.Query{
+			StartTime: time.Unix(1003620, 0),
+			EndTime:   time.Unix(1014420, 0),
+			Sample:    45,
+			Series:    []plot.QuerySeries{{Name: "series0", Source: "host1", Metric: "metric"}},
+		})
+		if err != nil {
+			test.Fatal(err)
+		} else if s4[0].Step != 240 {
+			test.Fatalf("\n%s: expected step 240\nbut got  %d", kind, s4[0].Step)
+		}
+
+		values := make(map[int64]plot.Value)
+		for _, p := range s1[0].Plots {
+			values[p.Time.Unix()] = p.Value
+		}
+
+		shared := 0
+		for _, p := range s4[0].Plots {
+			if value, ok := values[p.Time.Unix()]; ok {
+				if value.IsNaN() != p.Value.IsNaN() || !value.IsNaN() && value != p.Value {
+					test.Logf("\n%s: expected %g at %d\nbut got  %g", kind, value, p.Time.Unix(), p.Value)
+					test.Fail()
+					break
+				}
+
+				shared++
+			}
+		}
+
+		if shared != 15 {
+			test.Logf("\n%s: expected 15 shared plots\nbut got  %d", kind, shared)
+			test.Fail()
+		}
+
+		// Check that changing seed changes the series
+		settings["seed"] = 4321.0
+
+		c3, err := Connectors["synthetic"]("synthetic", settings)
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		s3, err := c3.GetPlots(context.Background(), &plot.Query{
+			StartTime: time.Unix(1000020, 0),
+			EndTime:   time.Unix(1007220, 0),
+			Series:    []plot.QuerySeries{{Name: "series0", Source: "host1", Metric: "metric"}},
+		})
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		if err := compareSeries(s1, s3); err == nil {
+			test.Logf("%s: expected different series for distinct seeds", kind)
+			test.Fail()
+		}
+	}
+}
+
+func Test_SyntheticRandom(test *testing.T) {
+	for i := int64(-1000); i < 1000; i++ {
+		if value := syntheticRandom(42, i); value < -1 || value >= 1 || math.IsNaN(value) {
+			test.Logf("\nExpected value in [-1, 1)\nbut got  %g", value)
+			test.Fail()
+			break
+		}
+	}
+}
+
+func syntheticTestSettings(seed float64) map[string]interface{} {
+	return map[string]interface{}{
+		"seed":    seed,
+		"sources": []interface{}{"host1", "host2"},
+		"metrics": map[string]interface{}{
+			"cpu.idle": map[string]interface{}{
+				"type":      "sine",
+				"period":    3600.0,
+				"amplitude": 5.0,
+				"offset":    50.0,
+				"noise":     2.0,
+				"gaps":      0.2,
+				"gap_size":  3.0,
+			},
+			"net.packets": map[string]interface{}{
+				"type":   "counter",
+				"period": 3600.0,
+				"rate":   10.0,
+			},
+		},
+	}
+}
@@ -0,0 +1,359 @@
+// +build whisper
+
+package connector
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/facette/facette/pkg/catalog"
+	"github.com/facette/facette/pkg/config"
+	"github.com/facette/facette/pkg/logger"
+	"github.com/facette/facette/pkg/plot"
+	"github.com/facette/facette/pkg/utils"
+)
+
+const (
+	whisperMetadataSize    int64 = 16
+	whisperArchiveInfoSize int64 = 12
+	whisperPointSize       int64 = 12
+)
+
+type whisperArchive struct {
+	offset int64
+	step   int64
+	points int64
+}
+
+func (archive whisperArchive) retention() int64 {
+	return archive.step * archive.points
+}
+
+type whisperHeader struct {
+	maxRetention int64
+	archives     []whisperArchive
+}
+
+// WhisperConnector represents the main structure of the Whisper connector.
+type WhisperConnector struct {
+	name    string
+	path    string
+	re      *regexp.Regexp
+	metrics map[string]map[string]string
+}
+
+func init() {
+	Connectors["whisper"] = func(name string, settings map[string]interface{}) (Connector, error) {
+		var (
+			pattern string
+			err     error
+		)
+
+		c := &WhisperConnector{
+			name:    name,
+			metrics: make(map[string]map[string]string),
+		}
+
+		if c.path, err = config.GetString(settings, "path", true); err != nil {
+			return nil, err
+		}
+		c.path = strings.TrimRight(c.path, "/")
+		if c.path == "" {
+			c.path = "."
+		}
+
+		if pattern, err = config.GetString(settings, "pattern", true); err != nil {
+			return nil, err
+		}
+
+		// Check and compile regexp pattern
+		if c.re, err = compilePattern(pattern); err != nil {
+			return nil, fmt.Errorf("unable to compile regexp pattern: %s", err)
+		}
+
+		return c, nil
+	}
+}
+
+// GetName returns the name of the current connector.
+func (c *WhisperConnector) GetName() string {
+	return c.name
+}
+
+// GetPlots retrieves time series data from origin based on a query and a time interval.
+func (c *WhisperConnector) GetPlots(ctx context.Context, query *plot.Query) ([]*plot.Series, error) {
+	var results []*plot.Series
+
+	if len(query.Series) == 0 {
+		return nil, fmt.Errorf("whisper[%s]: requested series list is empty", c.name)
+	}
+
+	for _, s := range query.Series {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("whisper[%s]: %s", c.name, err)
+		}
+
+		if _, ok := c.metrics[s.Source]; !ok {
+			return nil, fmt.Errorf("whisper[%s]: unknown source `%s'", c.name, s.Source)
+		} else if _, ok := c.metrics[s.Source][s.Metric]; !ok {
+			return nil, fmt.Errorf("whisper[%s]: unknown metric `%s' for source `%s'", c.name, s.Metric, s.Source)
+		}
+
+		series, err := whisperFetch(c.metrics[s.Source][s.Metric], query.StartTime, query.EndTime, query.Sample,
+			time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("whisper[%s]: %s", c.name, err)
+		}
+
+		series.Name = s.Name
+
+		results = append(results, series)
+	}
+
+	return results, nil
+}
+
+// Refresh triggers a full connector data update.
+func (c *WhisperConnector) Refresh(ctx context.Context, originName string, outputChan chan<- *catalog.Record) error {
+	// Search for files and parse their path for source/metric pairs
+	walkFunc := func(filePath string, fileInfo os.FileInfo, err error) error {
+		var sourceName, metricName string
+
+		// Stop walking if refresh has been cancelled
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// Report errors
+		if err != nil {
+			logger.Log(logger.LevelWarning, "connector", "whisper[%s]: error while walking: %s", c.name, err)
+			return nil
+		}
+
+		// Skip non-files
+		mode := fileInfo.Mode() & os.ModeType
+		if mode != 0 {
+			return nil
+		}
+
+		// Get pattern matches
+		m, err := matchSeriesPattern(c.re, strings.TrimPrefix(filePath, c.path+"/"))
+		if err != nil {
+			logger.Log(logger.LevelInfo, "connector", "whisper[%s]: file `%s' does not match pattern, ignoring",
+				c.name, filePath)
+			return nil
+		}
+
+		sourceName, metricName = m[0], m[1]
+
+		// Check for whisper file header validity
+		fd, err := os.Open(filePath)
+		if err != nil {
+			logger.Log(logger.LevelWarning, "connector", "whisper[%s]: %s", c.name, err)
+			return nil
+		}
+		defer fd.Close()
+
+		if _, err := whisperReadHeader(fd); err != nil {
+			logger.Log(logger.LevelWarning, "connector", "whisper[%s]: invalid file `%s': %s", c.name, filePath, err)
+			return nil
+		}
+
+		if _, ok := c.metrics[sourceName]; !ok {
+			c.metrics[sourceName] = make(map[string]string)
+		}
+
+		c.metrics[sourceName][metricName] = filePath
+
+		outputChan <- &catalog.Record{
+			Origin:    originName,
+			Source:    sourceName,
+			Metric:    metricName,
+			Connector: c,
+		}
+
+		return nil
+	}
+
+	if err := utils.WalkDir(c.path, walkFunc); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func whisperReadHeader(fd *os.File) (*whisperHeader, error) {
+	fileInfo, err := fd.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("unable to stat file: %s", err)
+	}
+
+	buf := make([]byte, whisperMetadataSize)
+
+	if _, err := fd.ReadAt(buf, 0); err != nil {
+		return nil, fmt.Errorf("unable to read metadata: %s", err)
+	}
+
+	// Check archives count against file size before allocating, as it is read from untrusted data
+	count := int64(binary.BigEndian.Uint32(buf[12:16]))
+	if count == 0 {
+		return nil, fmt.Errorf("no archive found")
+	} else if whisperMetadataSize+count*whisperArchiveInfoSize > fileInfo.Size() {
+		return nil, fmt.Errorf("archives count %d exceeds file size", count)
+	}
+
+	header := &whisperHeader{
+		maxRetention: int64(binary.BigEndian.Uint32(buf[4:8])),
+		archives:     make([]whisperArchive, count),
+	}
+
+	buf = make([]byte, whisperArchiveInfoSize*count)
+
+	if _, err := fd.ReadAt(buf, whisperMetadataSize); err != nil {
+		return nil, fmt.Errorf("unable to read archives information: %s", err)
+	}
+
+	for i := range header.archives {
+		chunk := buf[int64(i)*whisperArchiveInfoSize:]
+
+		header.archives[i] = whisperArchive{
+			offset: int64(binary.BigEndian.Uint32(chunk[0:4])),
+			step:   int64(binary.BigEndian.Uint32(chunk[4:8])),
+			points: int64(binary.BigEndian.Uint32(chunk[8:12])),
+		}
+
+		if header.archives[i].step == 0 || header.archives[i].points == 0 {
+			return nil, fmt.Errorf("invalid archive #%d", i)
+		} else if header.archives[i].offset < whisperMetadataSize+count*whisperArchiveInfoSize ||
+			header.archives[i].offset+header.archives[i].points*whisperPointSize > fileInfo.Size() {
+			return nil, fmt.Errorf("archive #%d exceeds file bounds", i)
+		}
+	}
+
+	return header, nil
+}
+
+func whisperSelectArchive(header *whisperHeader, startTime, endTime, now time.Time, sample int) whisperArchive {
+	var selected *whisperArchive
+
+	diff := now.Unix() - startTime.Unix()
+	step := int64(getPlotStep(startTime, endTime, sample))
+
+	// Archives are stored from the highest to the lowest precision: select the coarsest archive covering the
+	// requested range without exceeding the requested sample step, or the most precise one covering it otherwise.
+	for i, archive := range header.archives {
+		if archive.retention() < diff {
+			continue
+		}
+
+		if selected == nil || archive.step <= step {
+			selected = &header.archives[i]
+		}
+
+		if archive.step >= step {
+			break
+		}
+	}
+
+	if selected == nil {
+		return header.archives[len(header.archives)-1]
+	}
+
+	return *selected
+}
+
+func whisperFetch(filePath string, startTime, endTime time.Time, sample int, now time.Time) (*plot.Series, error) {
+	fd, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	header, err := whisperReadHeader(fd)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file `%s': %s", filePath, err)
+	}
+
+	// Clamp requested range to the file retention
+	from, until := startTime.Unix(), endTime.Unix()
+
+	if oldest := now.Unix() - header.maxRetention; from < oldest {
+		from = oldest
+	}
+
+	if until > now.Unix() {
+		until = now.Unix()
+	}
+
+	if from >= until {
+		return &plot.Series{}, nil
+	}
+
+	archive := whisperSelectArchive(header, time.Unix(from, 0), time.Unix(until, 0), now, sample)
+
+	fromInterval := from - from%archive.step + archive.step
+	untilInterval := until - until%archive.step + archive.step
+	count := (untilInterval - fromInterval) / archive.step
+
+	series := &plot.Series{
+		Step:  int(archive.step),
+		Plots: make([]plot.Plot, count),
+	}
+
+	for i := range series.Plots {
+		series.Plots[i] = plot.Plot{
+			Time:  time.Unix(fromInterval+int64(i)*archive.step, 0),
+			Value: plot.Value(math.NaN()),
+		}
+	}
+
+	// Read archive base interval
+	buf := make([]byte, whisperPointSize)
+	if _, err := fd.ReadAt(buf, archive.offset); err != nil {
+		return nil, fmt.Errorf("unable to read archive: %s", err)
+	}
+
+	baseInterval := int64(binary.BigEndian.Uint32(buf[0:4]))
+	if baseInterval == 0 {
+		return series, nil
+	}
+
+	// Read archive points, wrapping around the end of the archive if needed
+	index := ((fromInterval-baseInterval)/archive.step%archive.points + archive.points) % archive.points
+
+	buf = make([]byte, whisperPointSize*count)
+
+	for read := int64(0); read < count; {
+		chunk := count - read
+		if chunk > archive.points-index {
+			chunk = archive.points - index
+		}
+
+		if _, err := fd.ReadAt(buf[read*whisperPointSize:(read+chunk)*whisperPointSize],
+			archive.offset+index*whisperPointSize); err != nil {
+			return nil, fmt.Errorf("unable to read archive: %s", err)
+		}
+
+		read += chunk
+		index = (index + chunk) % archive.points
+	}
+
+	// Discard points not matching their expected interval (stale data from a previous archive cycle)
+	for i := range series.Plots {
+		point := buf[int64(i)*whisperPointSize:]
+
+		if int64(binary.BigEndian.Uint32(point[0:4])) != series.Plots[i].Time.Unix() {
+			continue
+		}
+
+		series.Plots[i].Value = plot.Value(math.Float64frombits(binary.BigEndian.Uint64(point[4:12])))
+	}
+
+	return series, nil
+}
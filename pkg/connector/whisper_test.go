@@ -0,0 +1,195 @@
+// +build whisper
+
+package connector
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/facette/facette/pkg/catalog"
+	"github.com/facette/facette/pkg/plot"
+)
+
+func Test_WhisperRefresh(test *testing.T) {
+	expected := []catalog.Record{
+		{Origin: "whisper", Source: "host1", Metric: "load/shortterm"},
+	}
+
+	dirPath, err := ioutil.TempDir("", "facette")
+	if err != nil {
+		test.Fatal(err)
+	}
+	defer os.RemoveAll(dirPath)
+
+	os.MkdirAll(path.Join(dirPath, "host1", "load"), 0755)
+
+	if err := createTestWhisperFile(path.Join(dirPath, "host1", "load", "shortterm.wsp"), 0); err != nil {
+		test.Fatal(err)
+	}
+
+	ioutil.WriteFile(path.Join(dirPath, "host1", "load", "invalid.wsp"), []byte("invalid"), 0644)
+
+	c, err := Connectors["whisper"]("whisper", map[string]interface{}{
+		"path":    dirPath,
+		"pattern": "(?P<source>[^/]+)/(?P<metric>.+)\\.wsp$",
+	})
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	actual := runTestRefresh(c, "whisper")
+
+	if !reflect.DeepEqual(expected, actual) {
+		test.Logf("\nExpected %s\nbut got  %s", expected, actual)
+		test.Fail()
+	}
+}
+
+func Test_WhisperFetch(test *testing.T) {
+	now := time.Unix(100000, 0)
+
+	filePath := path.Join(os.TempDir(), "facette-whisper-test.wsp")
+	defer os.Remove(filePath)
+
+	if err := createTestWhisperFile(filePath, now.Unix()); err != nil {
+		test.Fatal(err)
+	}
+
+	// Precise archive (10s step) covering the last 100 seconds
+	expected := &plot.Series{Step: 10, Plots: []plot.Plot{
+		{Time: time.Unix(99950, 0), Value: 99950},
+		{Time: time.Unix(99960, 0), Value: 99960},
+		{Time: time.Unix(99970, 0), Value: 99970},
+		{Time: time.Unix(99980, 0), Value: plot.Value(math.NaN())},
+		{Time: time.Unix(99990, 0), Value: 99990},
+		{Time: time.Unix(100000, 0), Value: 100000},
+	}}
+
+	actual, err := whisperFetch(filePath, time.Unix(99945, 0), now, 100, now)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	if err := compareSeries([]*plot.Series{expected}, []*plot.Series{actual}); err != nil {
+		test.Log(err)
+		test.Fail()
+	}
+
+	// Coarse archive (60s step) selected as the range exceeds the precise archive retention
+	actual, err = whisperFetch(filePath, time.Unix(99700, 0), time.Unix(99900, 0), 100, now)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	expectedTimes := []int64{99720, 99780, 99840, 99900}
+	actualTimes := make([]int64, len(actual.Plots))
+	for i := range actual.Plots {
+		actualTimes[i] = actual.Plots[i].Time.Unix()
+	}
+
+	if actual.Step != 60 || !reflect.DeepEqual(expectedTimes, actualTimes) {
+		test.Logf("\nExpected step 60 and times %v\nbut got  step %d and times %v", expectedTimes, actual.Step,
+			actualTimes)
+		test.Fail()
+	}
+}
+
+func Test_WhisperReadHeader(test *testing.T) {
+	filePath := path.Join(os.TempDir(), "facette-whisper-test.wsp")
+	defer os.Remove(filePath)
+
+	for _, entry := range []struct {
+		alter func(fd *os.File) error
+		err   string
+	}{
+		{func(fd *os.File) error { return nil }, ""},
+		{func(fd *os.File) error {
+			_, err := fd.WriteAt([]byte{0xff, 0xff, 0xff, 0xff}, 12)
+			return err
+		}, "archives count 4294967295 exceeds file size"},
+		{func(fd *os.File) error { return fd.Truncate(whisperMetadataSize + 2*whisperArchiveInfoSize + 100) },
+			"archive #0 exceeds file bounds"},
+		{func(fd *os.File) error {
+			_, err := fd.WriteAt([]byte{0xff, 0xff, 0xff, 0xff}, whisperMetadataSize+whisperArchiveInfoSize+8)
+			return err
+		}, "archive #1 exceeds file bounds"},
+	} {
+		if err := createTestWhisperFile(filePath, 0); err != nil {
+			test.Fatal(err)
+		}
+
+		fd, err := os.OpenFile(filePath, os.O_RDWR, 0644)
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		if err := entry.alter(fd); err != nil {
+			test.Fatal(err)
+		}
+
+		errMsg := ""
+		if _, err := whisperReadHeader(fd); err != nil {
+			errMsg = err.Error()
+		}
+
+		fd.Close()
+
+		if errMsg != entry.err {
+			test.Logf("\nExpected error %q\nbut got  %q", entry.err, errMsg)
+			test.Fail()
+		}
+	}
+}
+
+func createTestWhisperFile(filePath string, now int64) error {
+	archives := []whisperArchive{
+		{step: 10, points: 12},
+		{step: 60, points: 60},
+	}
+
+	offset := whisperMetadataSize + whisperArchiveInfoSize*int64(len(archives))
+
+	buf := make([]byte, offset)
+	binary.BigEndian.PutUint32(buf[0:4], 1)
+	binary.BigEndian.PutUint32(buf[4:8], uint32(archives[1].retention()))
+	binary.BigEndian.PutUint32(buf[8:12], math.Float32bits(0.5))
+	binary.BigEndian.PutUint32(buf[12:16], uint32(len(archives)))
+
+	for i := range archives {
+		archives[i].offset = offset
+
+		chunk := buf[whisperMetadataSize+int64(i)*whisperArchiveInfoSize:]
+		binary.BigEndian.PutUint32(chunk[0:4], uint32(archives[i].offset))
+		binary.BigEndian.PutUint32(chunk[4:8], uint32(archives[i].step))
+		binary.BigEndian.PutUint32(chunk[8:12], uint32(archives[i].points))
+
+		offset += archives[i].points * whisperPointSize
+	}
+
+	buf = append(buf, make([]byte, offset-int64(len(buf)))...)
+
+	if now > 0 {
+		// Fill archives with their timestamp as value, leaving a gap at 99980
+		for _, archive := range archives {
+			for ts := now - now%archive.step - archive.retention() + archive.step; ts <= now; ts += archive.step {
+				if ts == 99980 {
+					continue
+				}
+
+				index := ts / archive.step % archive.points
+				point := buf[archive.offset+index*whisperPointSize:]
+
+				binary.BigEndian.PutUint32(point[0:4], uint32(ts))
+				binary.BigEndian.PutUint64(point[4:12], math.Float64bits(float64(ts)))
+			}
+		}
+	}
+
+	return ioutil.WriteFile(filePath, buf, 0644)
+}
@@ -3,6 +3,8 @@ package library
 import (
 	"fmt"
 	"strings"
+
+	"github.com/facette/facette/pkg/plot"
 )
 
 const (
@@ -77,6 +79,62 @@ func (graph *Graph) String() string {
 	)
 }
 
+// Validate checks the graph series expressions, ensuring they can be parsed and only reference series defined in the
+// graph: plain series (outside of operation groups), operation groups or expression series preceding them.
+func (graph *Graph) Validate() error {
+	// Skip templates as series names might not be expanded yet
+	if graph.Template {
+		return nil
+	}
+
+	names := make(map[string]bool)
+
+	for _, group := range graph.Groups {
+		if group == nil {
+			continue
+		} else if group.Type != plot.OperTypeNone && group.Type != plot.OperTypeNormalize {
+			// Operation groups only yield their resulting series, members can't be referenced
+			names[group.Name] = true
+			continue
+		}
+
+		for _, series := range group.Series {
+			if series != nil && series.Expr == "" {
+				names[series.Name] = true
+			}
+		}
+	}
+
+	for _, group := range graph.Groups {
+		if group == nil {
+			continue
+		}
+
+		for _, series := range group.Series {
+			if series == nil || series.Expr == "" {
+				continue
+			} else if group.Type != plot.OperTypeNone {
+				return fmt.Errorf("expression series `%s' can't be part of an operation group", series.Name)
+			}
+
+			expr, err := plot.ParseExpr(series.Expr)
+			if err != nil {
+				return fmt.Errorf("invalid `%s' series expression: %s", series.Name, err)
+			}
+
+			for _, name := range expr.Series() {
+				if !names[name] {
+					return fmt.Errorf("invalid `%s' series expression: unknown `%s' series", series.Name, name)
+				}
+			}
+
+			names[series.Name] = true
+		}
+	}
+
+	return nil
+}
+
 // OperGroup represents an operation group entry.
 type OperGroup struct {
 	Name    string                 `json:"name"`
@@ -109,16 +167,18 @@ type Series struct {
 	Origin  string                 `json:"origin"`
 	Source  string                 `json:"source"`
 	Metric  string                 `json:"metric"`
+	Expr    string                 `json:"expr,omitempty"`
 	Options map[string]interface{} `json:"options"`
 }
 
 func (series *Series) String() string {
 	return fmt.Sprintf(
-		"Series{Name:%q Origin:%q Source:%q Metric:%q Options:%v}",
+		"Series{Name:%q Origin:%q Source:%q Metric:%q Expr:%q Options:%v}",
 		series.Name,
 		series.Origin,
 		series.Source,
 		series.Metric,
+		series.Expr,
 		series.Options,
 	)
 }
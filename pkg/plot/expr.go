@@ -0,0 +1,409 @@
+package plot
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expr represents a parsed series arithmetic expression, e.g. `errors / requests * 100'. Expressions support the
+// four basic arithmetic operators, numeric constants, series references either given as bare names or quoted (e.g.
+// "host1 (cpu.idle)") and a set of functions applied on each plot value.
+type Expr struct {
+	root   exprNode
+	series []string
+}
+
+type exprNode interface {
+	eval(series map[string]Series, index int) float64
+}
+
+type exprNumber float64
+
+type exprSeries string
+
+type exprNegate struct {
+	node exprNode
+}
+
+type exprBinary struct {
+	op          rune
+	left, right exprNode
+}
+
+type exprCall struct {
+	function exprFunction
+	args     []exprNode
+}
+
+type exprFunction struct {
+	args int
+	call func(args []float64) float64
+}
+
+var exprFunctions = map[string]exprFunction{
+	"abs":   {1, func(args []float64) float64 { return math.Abs(args[0]) }},
+	"ceil":  {1, func(args []float64) float64 { return math.Ceil(args[0]) }},
+	"exp":   {1, func(args []float64) float64 { return math.Exp(args[0]) }},
+	"floor": {1, func(args []float64) float64 { return math.Floor(args[0]) }},
+	"log":   {1, func(args []float64) float64 { return math.Log(args[0]) }},
+	"log10": {1, func(args []float64) float64 { return math.Log10(args[0]) }},
+	"max":   {2, func(args []float64) float64 { return math.Max(args[0], args[1]) }},
+	"min":   {2, func(args []float64) float64 { return math.Min(args[0], args[1]) }},
+	"pow":   {2, func(args []float64) float64 { return math.Pow(args[0], args[1]) }},
+	"sqrt":  {1, func(args []float64) float64 { return math.Sqrt(args[0]) }},
+}
+
+// ParseExpr parses a series arithmetic expression.
+func ParseExpr(input string) (*Expr, error) {
+	tokens, err := lexExpr(input)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &exprParser{tokens: tokens, seen: make(map[string]bool)}
+
+	root, err := parser.parseSum()
+	if err != nil {
+		return nil, err
+	} else if token := parser.peek(); token.kind != exprTokenEOF {
+		return nil, fmt.Errorf("unexpected `%s' at position %d", token.value, token.pos)
+	}
+
+	if len(parser.series) == 0 {
+		return nil, fmt.Errorf("expression must reference at least one series")
+	}
+
+	return &Expr{root: root, series: parser.series}, nil
+}
+
+// Series returns the names of the series referenced by the expression, in order of appearance.
+func (expr *Expr) Series() []string {
+	return expr.series
+}
+
+// Eval evaluates the expression on a set of normalized series, returning a new series. Non-finite results (e.g.
+// division by zero) are reported as missing plots.
+func (expr *Expr) Eval(series map[string]Series) (Series, error) {
+	var plotsCount int
+
+	for i, name := range expr.series {
+		if _, ok := series[name]; !ok {
+			return Series{}, fmt.Errorf("unknown `%s' series", name)
+		} else if i == 0 {
+			plotsCount = len(series[name].Plots)
+		} else if len(series[name].Plots) != plotsCount {
+			return Series{}, fmt.Errorf("series `%s' and `%s' are not aligned", expr.series[0], name)
+		}
+	}
+
+	reference := series[expr.series[0]]
+
+	result := Series{
+		Plots: make([]Plot, plotsCount),
+		Step:  reference.Step,
+	}
+
+	for i := range result.Plots {
+		value := expr.root.eval(series, i)
+		if math.IsInf(value, 0) {
+			value = math.NaN()
+		}
+
+		result.Plots[i] = Plot{Time: reference.Plots[i].Time, Value: Value(value)}
+	}
+
+	return result, nil
+}
+
+func (node exprNumber) eval(series map[string]Series, index int) float64 {
+	return float64(node)
+}
+
+func (node exprSeries) eval(series map[string]Series, index int) float64 {
+	return float64(series[string(node)].Plots[index].Value)
+}
+
+func (node exprNegate) eval(series map[string]Series, index int) float64 {
+	return -node.node.eval(series, index)
+}
+
+func (node exprBinary) eval(series map[string]Series, index int) float64 {
+	left, right := node.left.eval(series, index), node.right.eval(series, index)
+
+	switch node.op {
+	case '+':
+		return left + right
+
+	case '-':
+		return left - right
+
+	case '*':
+		return left * right
+	}
+
+	return left / right
+}
+
+func (node exprCall) eval(series map[string]Series, index int) float64 {
+	args := make([]float64, len(node.args))
+	for i, arg := range node.args {
+		args[i] = arg.eval(series, index)
+	}
+
+	return node.function.call(args)
+}
+
+const (
+	exprTokenEOF = iota
+	exprTokenNumber
+	exprTokenName
+	exprTokenString
+	exprTokenOperator
+)
+
+type exprToken struct {
+	kind  int
+	value string
+	pos   int
+}
+
+func lexExpr(input string) ([]exprToken, error) {
+	var tokens []exprToken
+
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case strings.ContainsRune("+-*/(),", r):
+			tokens = append(tokens, exprToken{kind: exprTokenOperator, value: string(r), pos: i + 1})
+			i++
+
+		case r == '"' || r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+
+			if end == len(runes) {
+				return nil, fmt.Errorf("unterminated series name at position %d", i+1)
+			}
+
+			tokens = append(tokens, exprToken{kind: exprTokenString, value: string(runes[i+1 : end]), pos: i + 1})
+			i = end + 1
+
+		case unicode.IsDigit(r) || r == '.':
+			end := i
+			for end < len(runes) && (unicode.IsDigit(runes[end]) || runes[end] == '.') {
+				end++
+			}
+
+			// Handle exponent notation (e.g. 1e6)
+			if end < len(runes) && (runes[end] == 'e' || runes[end] == 'E') {
+				end++
+				if end < len(runes) && (runes[end] == '+' || runes[end] == '-') {
+					end++
+				}
+
+				for end < len(runes) && unicode.IsDigit(runes[end]) {
+					end++
+				}
+			}
+
+			tokens = append(tokens, exprToken{kind: exprTokenNumber, value: string(runes[i:end]), pos: i + 1})
+			i = end
+
+		case unicode.IsLetter(r) || r == '_':
+			end := i
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) ||
+				runes[end] == '_' || runes[end] == '.') {
+				end++
+			}
+
+			tokens = append(tokens, exprToken{kind: exprTokenName, value: string(runes[i:end]), pos: i + 1})
+			i = end
+
+		default:
+			return nil, fmt.Errorf("unexpected `%c' at position %d", r, i+1)
+		}
+	}
+
+	return append(tokens, exprToken{kind: exprTokenEOF, value: "end of expression", pos: len(runes) + 1}), nil
+}
+
+type exprParser struct {
+	tokens []exprToken
+	index  int
+	series []string
+	seen   map[string]bool
+}
+
+func (parser *exprParser) peek() exprToken {
+	return parser.tokens[parser.index]
+}
+
+func (parser *exprParser) next() exprToken {
+	token := parser.tokens[parser.index]
+	if token.kind != exprTokenEOF {
+		parser.index++
+	}
+
+	return token
+}
+
+func (parser *exprParser) isOperator(operators string) bool {
+	token := parser.peek()
+	return token.kind == exprTokenOperator && strings.Contains(operators, token.value)
+}
+
+func (parser *exprParser) expect(operator string) error {
+	if token := parser.next(); token.kind != exprTokenOperator || token.value != operator {
+		return fmt.Errorf("expected `%s' but got `%s' at position %d", operator, token.value, token.pos)
+	}
+
+	return nil
+}
+
+func (parser *exprParser) parseSum() (exprNode, error) {
+	node, err := parser.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+
+	for parser.isOperator("+-") {
+		op := rune(parser.next().value[0])
+
+		right, err := parser.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+
+		node = exprBinary{op: op, left: node, right: right}
+	}
+
+	return node, nil
+}
+
+func (parser *exprParser) parseProduct() (exprNode, error) {
+	node, err := parser.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for parser.isOperator("*/") {
+		op := rune(parser.next().value[0])
+
+		right, err := parser.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		node = exprBinary{op: op, left: node, right: right}
+	}
+
+	return node, nil
+}
+
+func (parser *exprParser) parseUnary() (exprNode, error) {
+	if parser.isOperator("+-") {
+		negate := parser.next().value == "-"
+
+		node, err := parser.parseUnary()
+		if err != nil || !negate {
+			return node, err
+		}
+
+		return exprNegate{node: node}, nil
+	}
+
+	return parser.parsePrimary()
+}
+
+func (parser *exprParser) parsePrimary() (exprNode, error) {
+	token := parser.next()
+
+	switch token.kind {
+	case exprTokenNumber:
+		value, err := strconv.ParseFloat(token.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number `%s' at position %d", token.value, token.pos)
+		}
+
+		return exprNumber(value), nil
+
+	case exprTokenName:
+		if parser.isOperator("(") {
+			return parser.parseCall(token)
+		}
+
+		return parser.addSeries(token.value), nil
+
+	case exprTokenString:
+		return parser.addSeries(token.value), nil
+
+	case exprTokenOperator:
+		if token.value == "(" {
+			node, err := parser.parseSum()
+			if err != nil {
+				return nil, err
+			} else if err := parser.expect(")"); err != nil {
+				return nil, err
+			}
+
+			return node, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unexpected `%s' at position %d", token.value, token.pos)
+}
+
+func (parser *exprParser) parseCall(name exprToken) (exprNode, error) {
+	function, ok := exprFunctions[name.value]
+	if !ok {
+		return nil, fmt.Errorf("unknown `%s' function at position %d", name.value, name.pos)
+	}
+
+	parser.next()
+
+	node := exprCall{function: function}
+
+	for !parser.isOperator(")") {
+		if len(node.args) > 0 {
+			if err := parser.expect(","); err != nil {
+				return nil, err
+			}
+		}
+
+		arg, err := parser.parseSum()
+		if err != nil {
+			return nil, err
+		}
+
+		node.args = append(node.args, arg)
+	}
+
+	parser.next()
+
+	if len(node.args) != function.args {
+		return nil, fmt.Errorf("function `%s' expects %d argument(s) but got %d at position %d", name.value,
+			function.args, len(node.args), name.pos)
+	}
+
+	return node, nil
+}
+
+func (parser *exprParser) addSeries(name string) exprNode {
+	if !parser.seen[name] {
+		parser.series = append(parser.series, name)
+		parser.seen[name] = true
+	}
+
+	return exprSeries(name)
+}
@@ -0,0 +1,91 @@
+package plot
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func Test_ParseExpr(test *testing.T) {
+	for _, entry := range []struct {
+		input  string
+		series []string
+		err    string
+	}{
+		{"errors / requests * 100", []string{"errors", "requests"}, ""},
+		{"used / (used + free)", []string{"used", "free"}, ""},
+		{`-max("host1 (cpu.idle)", 'cpu.user') + 1e2`, []string{"host1 (cpu.idle)", "cpu.user"}, ""},
+		{"pow(a, 2) - sqrt(abs(b))", []string{"a", "b"}, ""},
+		{"1 + 2", nil, "expression must reference at least one series"},
+		{"a +", nil, "unexpected `end of expression' at position 4"},
+		{"(a + b", nil, "expected `)' but got `end of expression' at position 7"},
+		{"a b", nil, "unexpected `b' at position 3"},
+		{"a $ b", nil, "unexpected `$' at position 3"},
+		{"foo(a)", nil, "unknown `foo' function at position 1"},
+		{"pow(a)", nil, "function `pow' expects 2 argument(s) but got 1 at position 1"},
+		{`"a + b`, nil, "unterminated series name at position 1"},
+	} {
+		var (
+			series []string
+			errMsg string
+		)
+
+		expr, err := ParseExpr(entry.input)
+		if err != nil {
+			errMsg = err.Error()
+		} else {
+			series = expr.Series()
+		}
+
+		if errMsg != entry.err || !reflect.DeepEqual(entry.series, series) {
+			test.Logf("\nExpected %v (error: %q) for %q\nbut got  %v (error: %q)", entry.series, entry.err,
+				entry.input, series, errMsg)
+			test.Fail()
+		}
+	}
+}
+
+func Test_ExprEval(test *testing.T) {
+	nan := math.NaN()
+
+	testSeries := map[string]Series{
+		"errors":   newTestStepSeries(10, 1, 2, nan, 5, 0),
+		"requests": newTestStepSeries(10, 10, 40, 20, 0, 50),
+		"short":    newTestStepSeries(10, 1, 2),
+	}
+
+	for _, entry := range []struct {
+		input    string
+		expected Series
+		err      string
+	}{
+		{"errors / requests * 100", newTestStepSeries(10, 10, 5, nan, nan, 0), ""},
+		{"-(errors - 1) + max(errors, 1)", newTestStepSeries(10, 1, 1, nan, 1, 2), ""},
+		{"errors + unknown", Series{}, "unknown `unknown' series"},
+		{"errors + short", Series{}, "series `errors' and `short' are not aligned"},
+	} {
+		expr, err := ParseExpr(entry.input)
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		actual, err := expr.Eval(testSeries)
+		if entry.err != "" {
+			if err == nil || err.Error() != entry.err {
+				test.Logf("\nExpected error %q for %q\nbut got  %v", entry.err, entry.input, err)
+				test.Fail()
+			}
+
+			continue
+		} else if err != nil {
+			test.Logf("%s: %s", entry.input, err)
+			test.Fail()
+			continue
+		}
+
+		if err := compareSeries(entry.expected, actual); err != nil || actual.Step != 10 {
+			test.Logf("%s: %s", entry.input, err)
+			test.Fail()
+		}
+	}
+}
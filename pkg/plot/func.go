@@ -3,6 +3,7 @@ package plot
 import (
 	"fmt"
 	"math"
+	"sort"
 	"time"
 )
 
@@ -30,6 +31,18 @@ const (
 	OperTypeSum
 	// OperTypeNormalize represents a NORMALIZE operation group mode.
 	OperTypeNormalize
+	// OperTypeMin represents a MIN operation group mode.
+	OperTypeMin
+	// OperTypeMax represents a MAX operation group mode.
+	OperTypeMax
+	// OperTypeMedian represents a MEDIAN operation group mode.
+	OperTypeMedian
+	// OperTypePercentile represents a PERCENTILE operation group mode.
+	OperTypePercentile
+	// OperTypeStddev represents a STDDEV operation group mode.
+	OperTypeStddev
+	// OperTypeCount represents a COUNT operation group mode.
+	OperTypeCount
 )
 
 type plotBucket struct {
@@ -229,15 +242,115 @@ func Normalize(seriesList []Series, startTime, endTime time.Time, sample int, co
 
 // AverageSeries returns a new series averaging each series' datapoints.
 func AverageSeries(seriesList []Series) (Series, error) {
-	return operSeries(seriesList, OperTypeAverage)
+	return operSeries(seriesList, mean)
 }
 
 // SumSeries add series plots together and return the sum at each datapoint.
 func SumSeries(seriesList []Series) (Series, error) {
-	return operSeries(seriesList, OperTypeSum)
+	return operSeries(seriesList, sum)
 }
 
-func operSeries(seriesList []Series, operType int) (Series, error) {
+// MinSeries returns a new series with the minimal value of each series' datapoints.
+func MinSeries(seriesList []Series) (Series, error) {
+	return operSeries(seriesList, minimum)
+}
+
+// MaxSeries returns a new series with the maximal value of each series' datapoints.
+func MaxSeries(seriesList []Series) (Series, error) {
+	return operSeries(seriesList, maximum)
+}
+
+// MedianSeries returns a new series with the median value of each series' datapoints.
+func MedianSeries(seriesList []Series) (Series, error) {
+	return PercentileSeries(seriesList, 50)
+}
+
+// PercentileSeries returns a new series with the given percentile of each series' datapoints, linearly interpolated
+// between the closest ranks.
+func PercentileSeries(seriesList []Series, percentile float64) (Series, error) {
+	if percentile < 0 || percentile > 100 {
+		return Series{}, fmt.Errorf("percentile must be between 0 and 100")
+	}
+
+	return operSeries(seriesList, func(values []float64) float64 {
+		return percentileOf(values, percentile)
+	})
+}
+
+// StddevSeries returns a new series with the standard deviation of each series' datapoints.
+func StddevSeries(seriesList []Series) (Series, error) {
+	return operSeries(seriesList, stddev)
+}
+
+// CountSeries returns a new series with the number of series having a valid value at each datapoint.
+func CountSeries(seriesList []Series) (Series, error) {
+	series, err := operSeries(seriesList, func(values []float64) float64 {
+		return float64(len(values))
+	})
+	if err != nil {
+		return series, err
+	}
+
+	// Report datapoints without any valid value as zero rather than a gap
+	for i := range series.Plots {
+		if series.Plots[i].Value.IsNaN() {
+			series.Plots[i].Value = 0
+		}
+	}
+
+	return series, nil
+}
+
+// SelectSeries ranks series by one of their summary values and returns the count first ones, either the highest or
+// the lowest ones, along with the remaining series. Series lacking a valid summary value are ranked last.
+func SelectSeries(seriesList []Series, key string, count int, top bool) ([]Series, []Series) {
+	ranking := seriesRanking{list: make([]Series, len(seriesList)), key: key, top: top}
+	copy(ranking.list, seriesList)
+
+	sort.Stable(ranking)
+
+	if count > len(ranking.list) {
+		count = len(ranking.list)
+	}
+
+	return ranking.list[:count], ranking.list[count:]
+}
+
+type seriesRanking struct {
+	list []Series
+	key  string
+	top  bool
+}
+
+func (r seriesRanking) Len() int {
+	return len(r.list)
+}
+
+func (r seriesRanking) Less(i, j int) bool {
+	a, b := r.value(i), r.value(j)
+
+	if a.IsNaN() || b.IsNaN() {
+		return !a.IsNaN()
+	} else if r.top {
+		return a > b
+	}
+
+	return a < b
+}
+
+func (r seriesRanking) Swap(i, j int) {
+	r.list[i], r.list[j] = r.list[j], r.list[i]
+}
+
+func (r seriesRanking) value(i int) Value {
+	if value, ok := r.list[i].Summary[r.key]; ok {
+		return value
+	}
+
+	return Value(math.NaN())
+}
+
+func operSeries(seriesList []Series, aggregate func([]float64) float64) (Series, error) {
 	nSeries := len(seriesList)
 
 	if nSeries == 0 {
@@ -251,30 +364,88 @@ func operSeries(seriesList []Series, operType int) (Series, error) {
 		Summary: make(map[string]Value),
 	}
 
+	values := make([]float64, 0, nSeries)
+
 	for plotIndex := 0; plotIndex < plotsCount; plotIndex++ {
 		operSeries.Plots[plotIndex].Time = seriesList[0].Plots[plotIndex].Time
 
-		sumCount := 0
+		values = values[:0]
 
 		for _, series := range seriesList {
 			if series.Plots[plotIndex].Value.IsNaN() {
 				continue
 			}
 
-			operSeries.Plots[plotIndex].Value += series.Plots[plotIndex].Value
-			sumCount++
+			values = append(values, float64(series.Plots[plotIndex].Value))
 		}
 
-		if sumCount == 0 {
+		if len(values) == 0 {
 			operSeries.Plots[plotIndex].Value = Value(math.NaN())
-		} else if operType == OperTypeAverage {
-			operSeries.Plots[plotIndex].Value /= Value(sumCount)
+		} else {
+			operSeries.Plots[plotIndex].Value = Value(aggregate(values))
 		}
 	}
 
 	return operSeries, nil
 }
 
+func sum(values []float64) float64 {
+	result := 0.0
+	for _, value := range values {
+		result += value
+	}
+
+	return result
+}
+
+func mean(values []float64) float64 {
+	return sum(values) / float64(len(values))
+}
+
+func minimum(values []float64) float64 {
+	result := values[0]
+	for _, value := range values[1:] {
+		result = math.Min(result, value)
+	}
+
+	return result
+}
+
+func maximum(values []float64) float64 {
+	result := values[0]
+	for _, value := range values[1:] {
+		result = math.Max(result, value)
+	}
+
+	return result
+}
+
+// percentileOf returns the given percentile of values, linearly interpolated between the closest ranks. Values are
+// sorted in place.
+func percentileOf(values []float64, percentile float64) float64 {
+	sort.Float64s(values)
+
+	rank := percentile / 100 * float64(len(values)-1)
+	rankInt := int(rank)
+
+	if rankInt >= len(values)-1 {
+		return values[len(values)-1]
+	}
+
+	return values[rankInt] + (rank-float64(rankInt))*(values[rankInt+1]-values[rankInt])
+}
+
+func stddev(values []float64) float64 {
+	avg := mean(values)
+
+	variance := 0.0
+	for _, value := range values {
+		variance += (value - avg) * (value - avg)
+	}
+
+	return math.Sqrt(variance / float64(len(values)))
+}
+
 func gcd(a, b int) int {
 	if a <= 0 || b <= 0 {
 		return 0
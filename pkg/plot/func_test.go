@@ -236,6 +236,95 @@ func Test_FuncSumSeries(test *testing.T) {
 	}
 }
 
+func Test_FuncOperSeries(test *testing.T) {
+	nan := Value(math.NaN())
+
+	testSeries := []Series{
+		{Step: 10, Plots: []Plot{{Value: 61}, {Value: 69}, {Value: 98}, {Value: nan}, {Value: 43}}},
+		{Step: 10, Plots: []Plot{{Value: nan}, {Value: 62}, {Value: 71}, {Value: nan}, {Value: 72}}},
+		{Step: 10, Plots: []Plot{{Value: 89}, {Value: 70}, {Value: nan}, {Value: nan}, {Value: 66}}},
+	}
+
+	for _, entry := range []struct {
+		name     string
+		oper     func([]Series) (Series, error)
+		expected []Plot
+	}{
+		{"min", MinSeries, []Plot{{Value: 61}, {Value: 62}, {Value: 71}, {Value: nan}, {Value: 43}}},
+		{"max", MaxSeries, []Plot{{Value: 89}, {Value: 70}, {Value: 98}, {Value: nan}, {Value: 72}}},
+		{"median", MedianSeries, []Plot{{Value: 75}, {Value: 69}, {Value: 84.5}, {Value: nan}, {Value: 66}}},
+		{"percentile", func(seriesList []Series) (Series, error) { return PercentileSeries(seriesList, 75) },
+			[]Plot{{Value: 82}, {Value: 69.5}, {Value: 91.25}, {Value: nan}, {Value: 69}}},
+		{"stddev", StddevSeries, []Plot{{Value: 14}, {Value: 3.559026084010437}, {Value: 13.5}, {Value: nan},
+			{Value: 12.498888839501783}}},
+		{"count", CountSeries, []Plot{{Value: 2}, {Value: 3}, {Value: 2}, {Value: 0}, {Value: 3}}},
+	} {
+		actual, err := entry.oper(testSeries)
+		if err != nil {
+			test.Logf("%s: %s", entry.name, err)
+			test.Fail()
+			continue
+		}
+
+		if !seriesEqual(entry.expected, actual.Plots, false) {
+			test.Logf("%s:\nExpected %+v\nbut got  %+v", entry.name, entry.expected, actual.Plots)
+			test.Fail()
+		}
+	}
+
+	if _, err := PercentileSeries(testSeries, 101); err == nil {
+		test.Logf("\nExpected error for out of range percentile")
+		test.Fail()
+	}
+}
+
+func Test_FuncSelectSeries(test *testing.T) {
+	testSeries := []Series{
+		{Name: "series0", Summary: map[string]Value{"max": 5}},
+		{Name: "series1", Summary: map[string]Value{"max": Value(math.NaN())}},
+		{Name: "series2", Summary: map[string]Value{"max": 9}},
+		{Name: "series3", Summary: map[string]Value{}},
+		{Name: "series4", Summary: map[string]Value{"max": 1}},
+	}
+
+	for _, entry := range []struct {
+		count    int
+		top      bool
+		selected []string
+		rest     []string
+	}{
+		{2, true, []string{"series2", "series0"}, []string{"series4", "series1", "series3"}},
+		{2, false, []string{"series4", "series0"}, []string{"series2", "series1", "series3"}},
+		{10, true, []string{"series2", "series0", "series4", "series1", "series3"}, []string{}},
+	} {
+		selected, rest := SelectSeries(testSeries, "max", entry.count, entry.top)
+
+		if names := seriesNames(selected); !reflect.DeepEqual(entry.selected, names) {
+			test.Logf("\nExpected %v\nbut got  %v", entry.selected, names)
+			test.Fail()
+		}
+
+		if names := seriesNames(rest); !reflect.DeepEqual(entry.rest, names) {
+			test.Logf("\nExpected %v\nbut got  %v", entry.rest, names)
+			test.Fail()
+		}
+	}
+
+	if testSeries[0].Name != "series0" {
+		test.Logf("\nExpected input series to be left untouched")
+		test.Fail()
+	}
+}
+
+func seriesNames(seriesList []Series) []string {
+	names := make([]string, len(seriesList))
+	for i, series := range seriesList {
+		names[i] = series.Name
+	}
+
+	return names
+}
+
 func Test_NormalizeAverage(test *testing.T) {
 	testSlice := []sampleTest{
 		sampleTest{5, []Plot{
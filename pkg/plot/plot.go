@@ -111,6 +111,102 @@ func (series *Series) Scale(factor Value) {
 	}
 }
 
+// Shift moves the series plots in time by a given offset.
+func (series *Series) Shift(offset time.Duration) {
+	for i := range series.Plots {
+		series.Plots[i].Time = series.Plots[i].Time.Add(offset)
+	}
+}
+
+// Derivative replaces the series plots values with their difference with the previous valid ones. If the series step
+// is known, differences are normalized on it to account for missing plots.
+func (series *Series) Derivative() {
+	series.derive(false, false, 0)
+}
+
+// Rate replaces the series plots values with their per-second variation since the previous valid ones.
+func (series *Series) Rate() {
+	series.derive(true, false, 0)
+}
+
+// NonNegativeDerivative behaves like Derivative, but handles decreasing counters: if a maximal value is given (i.e.
+// greater than zero) the counter is considered as having wrapped, otherwise as having been reset and the plot value
+// is left undefined.
+func (series *Series) NonNegativeDerivative(maxValue Value) {
+	series.derive(false, true, maxValue)
+}
+
+func (series *Series) derive(perSecond, nonNegative bool, maxValue Value) {
+	plots := make([]Plot, len(series.Plots))
+	last := -1
+
+	for i, plot := range series.Plots {
+		plots[i] = Plot{Time: plot.Time, Value: Value(math.NaN())}
+
+		if plot.Value.IsNaN() {
+			continue
+		}
+
+		if last != -1 {
+			previous := series.Plots[last]
+			delta := plot.Value - previous.Value
+			elapsed := plot.Time.Sub(previous.Time).Seconds()
+
+			if delta < 0 && nonNegative {
+				if maxValue > 0 && previous.Value <= maxValue {
+					delta = maxValue - previous.Value + plot.Value + 1
+				} else {
+					delta = Value(math.NaN())
+				}
+			}
+
+			if perSecond {
+				if elapsed > 0 {
+					delta /= Value(elapsed)
+				} else {
+					delta = Value(math.NaN())
+				}
+			} else if series.Step > 0 && elapsed > 0 {
+				delta *= Value(float64(series.Step) / elapsed)
+			}
+
+			plots[i].Value = delta
+		}
+
+		last = i
+	}
+
+	series.Plots = plots
+}
+
+// Integral replaces the series plots values with their cumulative sum, each value being weighted by the time elapsed
+// in seconds since the previous valid plot (or the series step for the first one).
+func (series *Series) Integral() {
+	var total Value
+
+	plots := make([]Plot, len(series.Plots))
+	last := -1
+
+	for i, plot := range series.Plots {
+		plots[i] = Plot{Time: plot.Time, Value: Value(math.NaN())}
+
+		if plot.Value.IsNaN() {
+			continue
+		}
+
+		if last != -1 {
+			total += plot.Value * Value(plot.Time.Sub(series.Plots[last].Time).Seconds())
+		} else {
+			total += plot.Value * Value(series.Step)
+		}
+
+		plots[i].Value = total
+		last = i
+	}
+
+	series.Plots = plots
+}
+
 // Summarize calculates the min/max/average/last and percentile values of a series of plots, and stores the results
 // into the Summary map.
 func (series *Series) Summarize(percentiles []float64) {
@@ -182,6 +182,91 @@ func Test_SeriesSummarize(test *testing.T) {
 
 }
 
+func Test_SeriesShift(test *testing.T) {
+	testSeries := newTestStepSeries(10, 1, 2, 3)
+	testSeries.Shift(-5 * time.Second)
+
+	expected := Series{Plots: []Plot{{Time: time.Unix(-5, 0), Value: 1}, {Time: time.Unix(5, 0), Value: 2},
+		{Time: time.Unix(15, 0), Value: 3}}}
+
+	if err := compareSeries(expected, testSeries); err != nil {
+		test.Logf("%s", err)
+		test.Fail()
+	}
+}
+
+func Test_SeriesDerivative(test *testing.T) {
+	nan := math.NaN()
+
+	testSeries := newTestStepSeries(10, 100, 110, nan, 150, 140)
+	testSeries.Derivative()
+
+	// Missing plot makes the 150 value span 2 steps
+	if err := compareSeries(newTestStepSeries(10, nan, 10, nan, 20, -10), testSeries); err != nil {
+		test.Logf("%s", err)
+		test.Fail()
+	}
+}
+
+func Test_SeriesRate(test *testing.T) {
+	nan := math.NaN()
+
+	testSeries := newTestStepSeries(10, 100, 110, nan, 150, 140)
+	testSeries.Rate()
+
+	if err := compareSeries(newTestStepSeries(10, nan, 1, nan, 2, -1), testSeries); err != nil {
+		test.Logf("%s", err)
+		test.Fail()
+	}
+}
+
+func Test_SeriesNonNegativeDerivative(test *testing.T) {
+	nan := math.NaN()
+
+	// Counter reset
+	testSeries := newTestStepSeries(10, 100, 110, 5, 15)
+	testSeries.NonNegativeDerivative(0)
+
+	if err := compareSeries(newTestStepSeries(10, nan, 10, nan, 10), testSeries); err != nil {
+		test.Logf("%s", err)
+		test.Fail()
+	}
+
+	// Counter wrap
+	testSeries = newTestStepSeries(10, 250, 254, 3, 13)
+	testSeries.NonNegativeDerivative(255)
+
+	if err := compareSeries(newTestStepSeries(10, nan, 4, 5, 10), testSeries); err != nil {
+		test.Logf("%s", err)
+		test.Fail()
+	}
+}
+
+func Test_SeriesIntegral(test *testing.T) {
+	nan := math.NaN()
+
+	testSeries := newTestStepSeries(10, 1, 2, nan, 3)
+	testSeries.Integral()
+
+	if err := compareSeries(newTestStepSeries(10, 10, 30, nan, 90), testSeries); err != nil {
+		test.Logf("%s", err)
+		test.Fail()
+	}
+}
+
+func newTestStepSeries(step int, values ...float64) Series {
+	series := Series{Step: step}
+
+	for i, value := range values {
+		series.Plots = append(series.Plots, Plot{
+			Time:  time.Unix(int64(i*step), 0),
+			Value: Value(value),
+		})
+	}
+
+	return series
+}
+
 func compareSeries(expected, actual Series) error {
 	for i := range expected.Plots {
 		if expected.Plots[i].Value.IsNaN() {
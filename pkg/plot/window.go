@@ -0,0 +1,163 @@
+package plot
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Window represents a moving window over a series of plots, either defined as a duration or as a number of plots.
+type Window struct {
+	Duration time.Duration
+	Points   int
+}
+
+// ParseWindow parses a window definition, either given as a number of plots or as a duration string (e.g. "5m").
+func ParseWindow(value interface{}) (Window, error) {
+	var window Window
+
+	switch v := value.(type) {
+	case float64:
+		window.Points = int(v)
+
+	case int:
+		window.Points = v
+
+	case string:
+		if points, err := strconv.Atoi(v); err == nil {
+			window.Points = points
+		} else if window.Duration, err = time.ParseDuration(v); err != nil {
+			return window, fmt.Errorf("invalid window `%s'", v)
+		}
+
+	default:
+		return window, fmt.Errorf("invalid window type %T", value)
+	}
+
+	if window.Duration < 0 || window.Points < 0 || window.Duration == 0 && window.Points == 0 {
+		return window, fmt.Errorf("window must be greater than zero")
+	}
+
+	return window, nil
+}
+
+// LeadIn returns the time span covered by the window, using the given step to estimate it for plots-based windows.
+func (window Window) LeadIn(step time.Duration) time.Duration {
+	if window.Duration > 0 {
+		return window.Duration
+	}
+
+	return time.Duration(window.Points) * step
+}
+
+// MovingAverage replaces the series plots values with the average of the valid values in the preceding window.
+func (series *Series) MovingAverage(window Window) {
+	series.moving(window, mean)
+}
+
+// MovingMedian replaces the series plots values with the median of the valid values in the preceding window.
+func (series *Series) MovingMedian(window Window) {
+	series.moving(window, func(values []float64) float64 {
+		return percentileOf(values, 50)
+	})
+}
+
+// MovingMin replaces the series plots values with the minimum of the valid values in the preceding window.
+func (series *Series) MovingMin(window Window) {
+	series.moving(window, minimum)
+}
+
+// MovingMax replaces the series plots values with the maximum of the valid values in the preceding window.
+func (series *Series) MovingMax(window Window) {
+	series.moving(window, maximum)
+}
+
+// MovingStddev replaces the series plots values with the standard deviation of the valid values in the preceding
+// window.
+func (series *Series) MovingStddev(window Window) {
+	series.moving(window, stddev)
+}
+
+// EWMA replaces the series plots values with their exponentially weighted moving average. For plots-based windows
+// the smoothing factor is 2/(N+1), whereas for duration-based ones the window is used as time constant so that
+// irregularly spaced plots are properly weighted.
+func (series *Series) EWMA(window Window) {
+	var (
+		average Value
+		last    = -1
+	)
+
+	plots := make([]Plot, len(series.Plots))
+
+	for i, plot := range series.Plots {
+		plots[i] = Plot{Time: plot.Time, Value: Value(math.NaN())}
+
+		if plot.Value.IsNaN() {
+			continue
+		}
+
+		if last == -1 {
+			average = plot.Value
+		} else {
+			var alpha float64
+
+			if window.Duration > 0 {
+				alpha = 1 - math.Exp(-plot.Time.Sub(series.Plots[last].Time).Seconds()/window.Duration.Seconds())
+			} else {
+				alpha = 2 / float64(window.Points+1)
+			}
+
+			average += Value(alpha) * (plot.Value - average)
+		}
+
+		plots[i].Value = average
+		last = i
+	}
+
+	series.Plots = plots
+}
+
+// TrimBefore removes the series plots preceding a given time, such as the lead-in data fetched for windowed
+// functions.
+func (series *Series) TrimBefore(startTime time.Time) {
+	for i, plot := range series.Plots {
+		if !plot.Time.Before(startTime) {
+			series.Plots = series.Plots[i:]
+			return
+		}
+	}
+
+	series.Plots = series.Plots[:0]
+}
+
+func (series *Series) moving(window Window, aggregate func([]float64) float64) {
+	plots := make([]Plot, len(series.Plots))
+	values := make([]float64, 0)
+
+	for i, plot := range series.Plots {
+		plots[i] = Plot{Time: plot.Time, Value: Value(math.NaN())}
+
+		// Keep gaps in the series
+		if plot.Value.IsNaN() {
+			continue
+		}
+
+		values = values[:0]
+
+		for j := i; j >= 0; j-- {
+			if window.Duration > 0 && plot.Time.Sub(series.Plots[j].Time) >= window.Duration ||
+				window.Points > 0 && i-j >= window.Points {
+				break
+			}
+
+			if !series.Plots[j].Value.IsNaN() {
+				values = append(values, float64(series.Plots[j].Value))
+			}
+		}
+
+		plots[i].Value = Value(aggregate(values))
+	}
+
+	series.Plots = plots
+}
@@ -0,0 +1,75 @@
+package plot
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func Test_ParseWindow(test *testing.T) {
+	for _, entry := range []struct {
+		value    interface{}
+		expected Window
+		fail     bool
+	}{
+		{5.0, Window{Points: 5}, false},
+		{"10", Window{Points: 10}, false},
+		{"5m", Window{Duration: 5 * time.Minute}, false},
+		{"0s", Window{}, true},
+		{-1.0, Window{}, true},
+		{"foo", Window{}, true},
+		{true, Window{}, true},
+	} {
+		actual, err := ParseWindow(entry.value)
+		if entry.fail != (err != nil) || !entry.fail && actual != entry.expected {
+			test.Logf("\nExpected %+v (failure: %v) for %v\nbut got  %+v (%v)", entry.expected, entry.fail,
+				entry.value, actual, err)
+			test.Fail()
+		}
+	}
+}
+
+func Test_SeriesMoving(test *testing.T) {
+	nan := math.NaN()
+
+	for _, entry := range []struct {
+		name     string
+		apply    func(*Series)
+		expected Series
+	}{
+		{"average/points", func(s *Series) { s.MovingAverage(Window{Points: 3}) },
+			newTestStepSeries(10, 3, 4, 6, nan, 12, 9)},
+		{"average/duration", func(s *Series) { s.MovingAverage(Window{Duration: 20 * time.Second}) },
+			newTestStepSeries(10, 3, 4, 7.5, nan, 14, 9)},
+		{"median", func(s *Series) { s.MovingMedian(Window{Points: 3}) },
+			newTestStepSeries(10, 3, 4, 5, nan, 12, 9)},
+		{"min", func(s *Series) { s.MovingMin(Window{Points: 3}) },
+			newTestStepSeries(10, 3, 3, 3, nan, 10, 4)},
+		{"max", func(s *Series) { s.MovingMax(Window{Points: 3}) },
+			newTestStepSeries(10, 3, 5, 10, nan, 14, 14)},
+		{"stddev", func(s *Series) { s.MovingStddev(Window{Points: 2}) },
+			newTestStepSeries(10, 0, 1, 2.5, nan, 0, 5)},
+		{"ewma", func(s *Series) { s.EWMA(Window{Points: 3}) },
+			newTestStepSeries(10, 3, 4, 7, nan, 10.5, 7.25)},
+	} {
+		testSeries := newTestStepSeries(10, 3, 5, 10, nan, 14, 4)
+		entry.apply(&testSeries)
+
+		if err := compareSeries(entry.expected, testSeries); err != nil {
+			test.Logf("%s: %s", entry.name, err)
+			test.Fail()
+		}
+	}
+}
+
+func Test_SeriesTrimBefore(test *testing.T) {
+	testSeries := newTestStepSeries(10, 1, 2, 3, 4)
+	testSeries.TrimBefore(time.Unix(15, 0))
+
+	if err := compareSeries(Series{Plots: []Plot{{Time: time.Unix(20, 0), Value: 3}, {Time: time.Unix(30, 0),
+		Value: 4}}}, testSeries); err != nil || len(testSeries.Plots) != 2 {
+
+		test.Logf("%s", err)
+		test.Fail()
+	}
+}
@@ -2,6 +2,7 @@ package provider
 
 import (
 	"regexp"
+	"strings"
 
 	"github.com/facette/facette/pkg/catalog"
 	"github.com/facette/facette/pkg/config"
@@ -12,25 +13,41 @@ import (
 type filterChain struct {
 	Input  chan *catalog.Record
 	output chan *catalog.Record
-	rules  []*config.ProviderFilterConfig
+	rules  []*filterRule
+	done   chan struct{}
+}
+
+type filterRule struct {
+	*config.ProviderFilterConfig
+	index int
+}
+
+type filterField struct {
+	name  string
+	value *string
 }
 
 func newFilterChain(filters []*config.ProviderFilterConfig, output chan *catalog.Record) filterChain {
 	chain := filterChain{
 		Input:  make(chan *catalog.Record),
 		output: output,
-		rules:  make([]*config.ProviderFilterConfig, 0),
+		rules:  make([]*filterRule, 0),
+		done:   make(chan struct{}),
 	}
 
 	actionSet := set.New(set.NonThreadSafe)
-	actionSet.Add("rewrite", "discard", "sieve")
+	actionSet.Add("rewrite", "discard", "sieve", "lowercase", "split", "map", "tag")
 
 	targetSet := set.New(set.NonThreadSafe)
 	targetSet.Add("any", "origin", "source", "metric")
 
-	for _, filter := range filters {
+	for i, filter := range filters {
 		if filter.Target == "" {
-			filter.Target = "any"
+			if filter.Action == "split" {
+				filter.Target = "metric"
+			} else {
+				filter.Target = "any"
+			}
 		}
 
 		if !actionSet.Has(filter.Action) {
@@ -51,118 +68,284 @@ func newFilterChain(filters []*config.ProviderFilterConfig, output chan *catalog
 
 		filter.PatternRegexp = re
 
-		chain.rules = append(chain.rules, filter)
+		if filter.Action == "split" && !checkSplitFilter(filter) {
+			continue
+		}
+
+		if !compileFilterConditions(filter, targetSet) {
+			continue
+		}
+
+		chain.rules = append(chain.rules, &filterRule{filter, i})
 	}
 
 	go func(chain filterChain) {
+		defer close(chain.done)
+
 		for record := range chain.Input {
+			// Forward flush records as is, as they must reach the catalog whatever the filters
+			if record.IsFlush() {
+				chain.output <- record
+				continue
+			}
+
 			// Keep a copy of original names
 			record.OriginalOrigin = record.Origin
 			record.OriginalSource = record.Source
 			record.OriginalMetric = record.Metric
 
-			// Forward record if no rule defined
-			if len(chain.rules) == 0 {
+			if chain.apply(record, nil) {
 				chain.output <- record
-				continue
 			}
+		}
+	}(chain)
 
-			for _, rule := range chain.rules {
-				if (rule.Target == "origin" || rule.Target == "any") && !rule.PatternRegexp.MatchString(record.Origin) {
-					if rule.Action == "sieve" {
-						logger.Log(
-							logger.LevelDebug,
-							"server",
-							"discard record %s, as origin doesn't match `%s' sieve pattern",
-							record,
-							rule.Pattern,
-						)
-						goto nextRecord
-					}
-				}
-
-				if (rule.Target == "origin" || rule.Target == "any") && rule.PatternRegexp.MatchString(record.Origin) {
-					if rule.Action == "discard" {
-						logger.Log(
-							logger.LevelDebug,
-							"server",
-							"discard record %s, as origin matches `%s' pattern",
-							record,
-							rule.Pattern,
-						)
-						goto nextRecord
-					}
-
-					if rule.Action == "rewrite" {
-						record.Origin = rule.PatternRegexp.ReplaceAllString(record.Origin, rule.Into)
-					}
-				}
-
-				if (rule.Target == "source" || rule.Target == "any") && !rule.PatternRegexp.MatchString(record.Source) {
-					if rule.Action == "sieve" {
-						logger.Log(
-							logger.LevelDebug,
-							"server",
-							"discard record %s, as source doesn't match `%s' sieve pattern",
-							record,
-							rule.Pattern,
-						)
-						goto nextRecord
-					}
-				}
-
-				if (rule.Target == "source" || rule.Target == "any") && rule.PatternRegexp.MatchString(record.Source) {
-					if rule.Action == "discard" {
-						logger.Log(
-							logger.LevelDebug,
-							"server",
-							"discard record %s, as source matches `%s' pattern",
-							record,
-							rule.Pattern,
-						)
-						goto nextRecord
-					}
-
-					if rule.Action == "rewrite" {
-						record.Source = rule.PatternRegexp.ReplaceAllString(record.Source, rule.Into)
-					}
-				}
-
-				if (rule.Target == "metric" || rule.Target == "any") && !rule.PatternRegexp.MatchString(record.Metric) {
-					if rule.Action == "sieve" {
-						logger.Log(
-							logger.LevelDebug,
-							"server",
-							"discard record %s, as metric doesn't match `%s' sieve pattern",
-							record,
-							rule.Pattern,
-						)
-						goto nextRecord
-					}
-				}
-
-				if (rule.Target == "metric" || rule.Target == "any") && rule.PatternRegexp.MatchString(record.Metric) {
-					if rule.Action == "discard" {
-						logger.Log(
-							logger.LevelDebug,
-							"server",
-							"discard record %s, as metric matches `%s' pattern",
-							record,
-							rule.Pattern,
-						)
-						goto nextRecord
-					}
-
-					if rule.Action == "rewrite" {
-						record.Metric = rule.PatternRegexp.ReplaceAllString(record.Metric, rule.Into)
-					}
-				}
+	return chain
+}
+
+func checkSplitFilter(filter *config.ProviderFilterConfig) bool {
+	if filter.Target != "metric" {
+		logger.Log(logger.LevelWarning, "provider", "split filter only applies to metric target, discarding")
+		return false
+	}
+
+	// Split pattern must capture both the source and the metric parts
+	groups := set.New(set.NonThreadSafe)
+	for _, name := range filter.PatternRegexp.SubexpNames() {
+		groups.Add(name)
+	}
+
+	if !groups.Has("source", "metric") {
+		logger.Log(logger.LevelWarning, "provider", "split filter pattern lacks `source' and `metric' named groups, "+
+			"discarding")
+		return false
+	}
+
+	return true
+}
+
+func compileFilterConditions(filter *config.ProviderFilterConfig, targetSet set.Interface) bool {
+	for _, condition := range filter.Conditions {
+		if condition.Target == "" {
+			condition.Target = "any"
+		}
+
+		if !targetSet.Has(condition.Target) {
+			logger.Log(logger.LevelWarning, "provider", "unknown `%s' filter condition target, discarding",
+				condition.Target)
+			return false
+		}
+
+		re, err := regexp.Compile(condition.Pattern)
+		if err != nil {
+			logger.Log(logger.LevelWarning, "provider", "unable to compile filter condition pattern: %s, discarding",
+				err)
+			return false
+		}
+
+		condition.PatternRegexp = re
+	}
+
+	return true
+}
+
+// apply applies the chain rules on a record, returning whether or not it has to be forwarded. Applied rules are
+// appended to the trace if any.
+func (chain filterChain) apply(record *catalog.Record, trace *FilterTrace) bool {
+	for _, rule := range chain.rules {
+		if !matchFilterConditions(rule, record) {
+			continue
+		}
+
+		if !applyFilterRule(rule, record, trace) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// trace applies the chain rules on a record without forwarding it, keeping track of the applied rules.
+func (chain filterChain) trace(record *catalog.Record) *FilterTrace {
+	record.OriginalOrigin = record.Origin
+	record.OriginalSource = record.Source
+	record.OriginalMetric = record.Metric
+
+	trace := &FilterTrace{
+		Input: FilterTraceRecord{Origin: record.Origin, Source: record.Source, Metric: record.Metric},
+		Steps: make([]*FilterTraceStep, 0),
+	}
+
+	if chain.apply(record, trace) {
+		trace.Kept = true
+		trace.Output = &FilterTraceRecord{
+			Origin:     record.Origin,
+			Source:     record.Source,
+			Metric:     record.Metric,
+			Attributes: record.Attributes,
+		}
+	}
+
+	return trace
+}
+
+func matchFilterConditions(rule *filterRule, record *catalog.Record) bool {
+	for _, condition := range rule.Conditions {
+		match := false
+
+		for _, field := range getFilterFields(record, condition.Target) {
+			if condition.PatternRegexp.MatchString(*field.value) {
+				match = true
+				break
 			}
+		}
 
-			chain.output <- record
-		nextRecord:
+		if !match {
+			return false
 		}
-	}(chain)
+	}
 
-	return chain
+	return true
+}
+
+func applyFilterRule(rule *filterRule, record *catalog.Record, trace *FilterTrace) bool {
+	for _, field := range getFilterFields(record, rule.Target) {
+		match := rule.PatternRegexp.FindStringSubmatchIndex(*field.value)
+		value := *field.value
+
+		switch {
+		case rule.Action == "sieve" && match == nil:
+			logger.Log(
+				logger.LevelDebug,
+				"server",
+				"discard record %s, as %s doesn't match `%s' sieve pattern",
+				record,
+				field.name,
+				rule.Pattern,
+			)
+			trace.discard(rule, field.name, value)
+			return false
+
+		case match == nil:
+			continue
+
+		case rule.Action == "discard":
+			logger.Log(
+				logger.LevelDebug,
+				"server",
+				"discard record %s, as %s matches `%s' pattern",
+				record,
+				field.name,
+				rule.Pattern,
+			)
+			trace.discard(rule, field.name, value)
+			return false
+
+		case rule.Action == "rewrite":
+			*field.value = rule.PatternRegexp.ReplaceAllString(*field.value, rule.Into)
+			trace.change(rule, field.name, value, *field.value)
+
+		case rule.Action == "lowercase":
+			*field.value = strings.ToLower(*field.value)
+			trace.change(rule, field.name, value, *field.value)
+
+		case rule.Action == "map":
+			if mapped, ok := rule.Table[*field.value]; ok {
+				*field.value = mapped
+				trace.change(rule, field.name, value, *field.value)
+			}
+
+		case rule.Action == "split":
+			source := record.Source
+
+			record.Source = string(rule.PatternRegexp.ExpandString(nil, "${source}", value, match))
+			record.Metric = string(rule.PatternRegexp.ExpandString(nil, "${metric}", value, match))
+
+			trace.change(rule, "source", source, record.Source)
+			trace.change(rule, "metric", value, record.Metric)
+
+		case rule.Action == "tag":
+			if record.Attributes == nil {
+				record.Attributes = make(map[string]string)
+			}
+
+			tags := make(map[string]string)
+			for key, tag := range rule.Tags {
+				tags[key] = string(rule.PatternRegexp.ExpandString(nil, tag, value, match))
+				record.Attributes[key] = tags[key]
+			}
+
+			trace.tag(rule, field.name, value, tags)
+
+			// Only tag using the first matching field
+			return true
+		}
+	}
+
+	return true
+}
+
+func getFilterFields(record *catalog.Record, target string) []filterField {
+	switch target {
+	case "origin":
+		return []filterField{{"origin", &record.Origin}}
+	case "source":
+		return []filterField{{"source", &record.Source}}
+	case "metric":
+		return []filterField{{"metric", &record.Metric}}
+	}
+
+	return []filterField{{"origin", &record.Origin}, {"source", &record.Source}, {"metric", &record.Metric}}
+}
+
+// FilterTrace represents the trace of a record going through a provider filters chain.
+type FilterTrace struct {
+	Input  FilterTraceRecord  `json:"input"`
+	Output *FilterTraceRecord `json:"output,omitempty"`
+	Kept   bool               `json:"kept"`
+	Steps  []*FilterTraceStep `json:"steps"`
+}
+
+// FilterTraceRecord represents a record state in a filters chain trace.
+type FilterTraceRecord struct {
+	Origin     string            `json:"origin"`
+	Source     string            `json:"source"`
+	Metric     string            `json:"metric"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// FilterTraceStep represents a filtering rule applied on a record in a filters chain trace.
+type FilterTraceStep struct {
+	Rule      int               `json:"rule"`
+	Action    string            `json:"action"`
+	Pattern   string            `json:"pattern"`
+	Field     string            `json:"field"`
+	Before    string            `json:"before"`
+	After     string            `json:"after,omitempty"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	Discarded bool              `json:"discarded,omitempty"`
+}
+
+func (trace *FilterTrace) add(rule *filterRule, step *FilterTraceStep) {
+	if trace == nil {
+		return
+	}
+
+	step.Rule = rule.index
+	step.Action = rule.Action
+	step.Pattern = rule.Pattern
+
+	trace.Steps = append(trace.Steps, step)
+}
+
+func (trace *FilterTrace) change(rule *filterRule, field, before, after string) {
+	trace.add(rule, &FilterTraceStep{Field: field, Before: before, After: after})
+}
+
+func (trace *FilterTrace) discard(rule *filterRule, field, value string) {
+	trace.add(rule, &FilterTraceStep{Field: field, Before: value, Discarded: true})
+}
+
+func (trace *FilterTrace) tag(rule *filterRule, field, value string, tags map[string]string) {
+	trace.add(rule, &FilterTraceStep{Field: field, Before: value, Tags: tags})
 }
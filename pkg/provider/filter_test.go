@@ -1,7 +1,11 @@
 package provider
 
 import (
+	"io/ioutil"
+	"os"
+	"path"
 	"reflect"
+	"strings"
 	"sync"
 	"testing"
 
@@ -116,9 +120,211 @@ func Test_Filter_Combined(test *testing.T) {
 	}
 }
 
-func runTestFilter(filters []*config.ProviderFilterConfig, outputCount int) []catalog.Record {
-	var filteredRecords []catalog.Record
+func Test_Filter_Lowercase(test *testing.T) {
+	expected := []catalog.Record{
+		{Origin: "collectd", Source: "host1.example.net", Metric: "load.load.shortterm",
+			OriginalOrigin: "collectd", OriginalSource: "HOST1.example.net", OriginalMetric: "load.load.shortterm"},
+		{Origin: "collectd", Source: "host1.example.net", Metric: "load.load.midterm",
+			OriginalOrigin: "collectd", OriginalSource: "HOST1.example.net", OriginalMetric: "load.load.midterm"},
+	}
+
+	actual := runTestFilterRecords([]*config.ProviderFilterConfig{
+		{Action: "lowercase", Target: "source"},
+	}, []catalog.Record{
+		{Origin: "collectd", Source: "HOST1.example.net", Metric: "load.load.shortterm"},
+		{Origin: "collectd", Source: "HOST1.example.net", Metric: "load.load.midterm"},
+	}, len(expected))
+
+	if !reflect.DeepEqual(expected, actual) {
+		test.Logf("\nExpected %s\nbut got  %s", expected, actual)
+		test.Fail()
+	}
+}
 
+func Test_Filter_Split(test *testing.T) {
+	expected := []catalog.Record{
+		{Origin: "graphite", Source: "host1", Metric: "cpu.idle",
+			OriginalOrigin: "graphite", OriginalSource: "graphite", OriginalMetric: "servers.host1.cpu.idle"},
+		{Origin: "graphite", Source: "graphite", Metric: "carbon.agents.updates",
+			OriginalOrigin: "graphite", OriginalSource: "graphite", OriginalMetric: "carbon.agents.updates"},
+	}
+
+	actual := runTestFilterRecords([]*config.ProviderFilterConfig{
+		{Action: "split", Pattern: "^servers\\.(?P<source>[^.]+)\\.(?P<metric>.+)$"},
+	}, []catalog.Record{
+		{Origin: "graphite", Source: "graphite", Metric: "servers.host1.cpu.idle"},
+		{Origin: "graphite", Source: "graphite", Metric: "carbon.agents.updates"},
+	}, len(expected))
+
+	if !reflect.DeepEqual(expected, actual) {
+		test.Logf("\nExpected %s\nbut got  %s", expected, actual)
+		test.Fail()
+	}
+}
+
+func Test_Filter_Map(test *testing.T) {
+	expected := []catalog.Record{
+		{Origin: "collectd", Source: "web1", Metric: "load.load.shortterm", OriginalOrigin: "collectd",
+			OriginalSource: "host1.example.net", OriginalMetric: "load.load.shortterm"},
+		{Origin: "collectd", Source: "host2.example.net", Metric: "load.load.shortterm", OriginalOrigin: "collectd",
+			OriginalSource: "host2.example.net", OriginalMetric: "load.load.shortterm"},
+	}
+
+	actual := runTestFilterRecords([]*config.ProviderFilterConfig{
+		{Action: "map", Target: "source", Table: map[string]string{"host1.example.net": "web1"}},
+	}, []catalog.Record{
+		{Origin: "collectd", Source: "host1.example.net", Metric: "load.load.shortterm"},
+		{Origin: "collectd", Source: "host2.example.net", Metric: "load.load.shortterm"},
+	}, len(expected))
+
+	if !reflect.DeepEqual(expected, actual) {
+		test.Logf("\nExpected %s\nbut got  %s", expected, actual)
+		test.Fail()
+	}
+}
+
+func Test_Filter_MapFile(test *testing.T) {
+	dirPath, err := ioutil.TempDir("", "facette")
+	if err != nil {
+		test.Fatal(err)
+	}
+	defer os.RemoveAll(dirPath)
+
+	// Store lookup tables along with the provider definitions, inline entries taking precedence. Tables must neither
+	// be parsed as definitions nor shadow the ones sharing their name.
+	for name, data := range map[string]string{
+		"collectd.json": `{"connector": {"type": "synthetic"}, "filters": [{"action": "map", "target": "source", ` +
+			`"file": "hosts.json", "table": {"host2.example.net": "web2"}}]}`,
+		"hosts.json": `{"host1.example.net": "web1", "host2.example.net": "db2"}`,
+		"web.json": `{"connector": {"type": "synthetic"}, "filters": [{"action": "map", "target": "source", ` +
+			`"file": "lookup/web.json"}]}`,
+		"lookup/web.json": `{"refresh_interval": "x", "host1.example.net": "web1"}`,
+	} {
+		if err := os.MkdirAll(path.Dir(path.Join(dirPath, name)), 0755); err != nil {
+			test.Fatal(err)
+		} else if err := ioutil.WriteFile(path.Join(dirPath, name), []byte(data), 0644); err != nil {
+			test.Fatal(err)
+		}
+	}
+
+	providers, err := (&config.Config{ProvidersDir: dirPath}).LoadProviders()
+	if err != nil {
+		test.Fatal(err)
+	} else if _, ok := providers["collectd"]; !ok || len(providers) != 2 {
+		test.Fatalf("\nExpected `collectd' and `web' providers\nbut got  %v", providers)
+	}
+
+	if web := providers["web"]; web == nil || len(web.Filters) != 1 || web.Filters[0].Table["refresh_interval"] != "x" {
+		test.Fatalf("\nExpected `web' provider with lookup table loaded\nbut got  %v", web)
+	}
+
+	expected := []catalog.Record{
+		{Origin: "collectd", Source: "web1", Metric: "load.load.shortterm", OriginalOrigin: "collectd",
+			OriginalSource: "host1.example.net", OriginalMetric: "load.load.shortterm"},
+		{Origin: "collectd", Source: "web2", Metric: "load.load.shortterm", OriginalOrigin: "collectd",
+			OriginalSource: "host2.example.net", OriginalMetric: "load.load.shortterm"},
+	}
+
+	actual := runTestFilterRecords(providers["collectd"].Filters, []catalog.Record{
+		{Origin: "collectd", Source: "host1.example.net", Metric: "load.load.shortterm"},
+		{Origin: "collectd", Source: "host2.example.net", Metric: "load.load.shortterm"},
+	}, len(expected))
+
+	if !reflect.DeepEqual(expected, actual) {
+		test.Logf("\nExpected %s\nbut got  %s", expected, actual)
+		test.Fail()
+	}
+}
+
+func Test_Filter_Tag(test *testing.T) {
+	actual := runTestFilter([]*config.ProviderFilterConfig{
+		{Action: "tag", Target: "source", Pattern: "^(host\\d+)\\.", Tags: map[string]string{"host": "$1"}},
+		{Action: "tag", Target: "metric", Pattern: "^interface-", Tags: map[string]string{"kind": "network"}},
+	}, 14)
+
+	for _, record := range actual {
+		expected := map[string]string{"host": strings.SplitN(record.Source, ".", 2)[0]}
+		if strings.HasPrefix(record.Metric, "interface-") {
+			expected["kind"] = "network"
+		}
+
+		if !reflect.DeepEqual(expected, record.Attributes) {
+			test.Logf("\nExpected %v attributes for %s\nbut got  %v", expected, record, record.Attributes)
+			test.Fail()
+		}
+	}
+}
+
+func Test_Filter_Conditions(test *testing.T) {
+	expected := []catalog.Record{
+		{Origin: "collectd", Source: "host1.example.net", Metric: "load.load.shortterm",
+			OriginalOrigin: "collectd", OriginalSource: "host1.example.net", OriginalMetric: "load.load.shortterm"},
+		{Origin: "collectd", Source: "host1.example.net", Metric: "load.load.midterm",
+			OriginalOrigin: "collectd", OriginalSource: "host1.example.net", OriginalMetric: "load.load.midterm"},
+		{Origin: "collectd", Source: "host1.example.net", Metric: "load.load.longterm",
+			OriginalOrigin: "collectd", OriginalSource: "host1.example.net", OriginalMetric: "load.load.longterm"},
+		{Origin: "collectd", Source: "host2.example.net", Metric: "interface-eth0.if_octets.rx",
+			OriginalOrigin: "collectd", OriginalSource: "host2.example.net",
+			OriginalMetric: "interface-eth0.if_octets.rx"},
+		{Origin: "collectd", Source: "host2.example.net", Metric: "interface-eth0.if_octets.tx",
+			OriginalOrigin: "collectd", OriginalSource: "host2.example.net",
+			OriginalMetric: "interface-eth0.if_octets.tx"},
+	}
+
+	actual := runTestFilter([]*config.ProviderFilterConfig{
+		{Action: "discard", Target: "metric", Pattern: "^interface", Conditions: []*config.ProviderFilterCondition{
+			{Target: "source", Pattern: "^host1\\."},
+		}},
+		{Action: "discard", Target: "metric", Pattern: "^load|packets", Conditions: []*config.ProviderFilterCondition{
+			{Target: "source", Pattern: "^host2\\."},
+			{Target: "origin", Pattern: "^collectd$"},
+		}},
+	}, len(expected))
+
+	if !reflect.DeepEqual(expected, actual) {
+		test.Logf("\nExpected %s\nbut got  %s", expected, actual)
+		test.Fail()
+	}
+}
+
+func Test_Filter_Trace(test *testing.T) {
+	prov := NewProvider("test", &config.ProviderConfig{Filters: []*config.ProviderFilterConfig{
+		{Action: "sieve", Target: "source", Pattern: "host1\\.example\\.net"},
+		{Action: "rewrite", Target: "metric", Pattern: "load\\.load", Into: "load"},
+	}}, catalog.NewCatalog())
+	defer prov.Close()
+
+	expected := []*FilterTrace{
+		{
+			Input:  FilterTraceRecord{Origin: "collectd", Source: "host1.example.net", Metric: "load.load.midterm"},
+			Output: &FilterTraceRecord{Origin: "collectd", Source: "host1.example.net", Metric: "load.midterm"},
+			Kept:   true,
+			Steps: []*FilterTraceStep{
+				{Rule: 1, Action: "rewrite", Pattern: "load\\.load", Field: "metric", Before: "load.load.midterm",
+					After: "load.midterm"},
+			},
+		},
+		{
+			Input: FilterTraceRecord{Origin: "collectd", Source: "host2.example.net", Metric: "load.load.midterm"},
+			Steps: []*FilterTraceStep{
+				{Rule: 0, Action: "sieve", Pattern: "host1\\.example\\.net", Field: "source",
+					Before: "host2.example.net", Discarded: true},
+			},
+		},
+	}
+
+	actual := prov.TraceFilters([]*catalog.Record{
+		{Origin: "collectd", Source: "host1.example.net", Metric: "load.load.midterm"},
+		{Origin: "collectd", Source: "host2.example.net", Metric: "load.load.midterm"},
+	})
+
+	if !reflect.DeepEqual(expected, actual) {
+		test.Logf("\nExpected %+v\nbut got  %+v", expected, actual)
+		test.Fail()
+	}
+}
+
+func runTestFilter(filters []*config.ProviderFilterConfig, outputCount int) []catalog.Record {
 	testRecords := []catalog.Record{
 		{Origin: "collectd", Source: "host1.example.net", Metric: "interface-eth0.if_octets.rx"},
 		{Origin: "collectd", Source: "host1.example.net", Metric: "interface-eth0.if_octets.tx"},
@@ -136,6 +342,14 @@ func runTestFilter(filters []*config.ProviderFilterConfig, outputCount int) []ca
 		{Origin: "collectd", Source: "host2.example.net", Metric: "load.load.longterm"},
 	}
 
+	return runTestFilterRecords(filters, testRecords, outputCount)
+}
+
+func runTestFilterRecords(filters []*config.ProviderFilterConfig, testRecords []catalog.Record,
+	outputCount int) []catalog.Record {
+
+	var filteredRecords []catalog.Record
+
 	wg := &sync.WaitGroup{}
 	wg.Add(outputCount)
 
@@ -2,29 +2,282 @@
 package provider
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
 	"time"
 
 	"github.com/facette/facette/pkg/catalog"
 	"github.com/facette/facette/pkg/config"
 	"github.com/facette/facette/pkg/connector"
+	"github.com/facette/facette/pkg/logger"
+)
+
+const (
+	// QueryStatsWindow represents the time window over which plot queries statistics are kept.
+	QueryStatsWindow time.Duration = 5 * time.Minute
+
+	queryStatsBuckets int = 5
 )
 
 // Provider represents a provider instance.
 type Provider struct {
-	Name        string
-	Config      *config.ProviderConfig
-	Catalog     *catalog.Catalog
-	Connector   connector.Connector
-	Filters     filterChain
-	LastRefresh time.Time
+	Name                string
+	Config              *config.ProviderConfig
+	Catalog             *catalog.Catalog
+	Connector           connector.Connector
+	Filters             filterChain
+	LastRefresh         time.Time
+	LastRefreshDuration time.Duration
+	LastRefreshError    error
+	LastRefreshRecords  int
+	RefreshCount        int
+	SnapshotRecords     int
+	refreshing          bool
+	refreshChan         chan struct{}
+	refreshRequests     chan struct{}
+	refreshLock         sync.Mutex
+	queryStats          [queryStatsBuckets]queryStatsBucket
+	sync.RWMutex
+}
+
+type queryStatsBucket struct {
+	period int64
+	total  int
+	errors int
 }
 
 // NewProvider creates a new provider instance.
 func NewProvider(name string, config *config.ProviderConfig, catalog *catalog.Catalog) *Provider {
 	return &Provider{
-		Name:    name,
-		Config:  config,
-		Catalog: catalog,
-		Filters: newFilterChain(config.Filters, catalog.RecordChan),
+		Name:            name,
+		Config:          config,
+		Catalog:         catalog,
+		Filters:         newFilterChain(config.Filters, catalog.RecordChan),
+		refreshChan:     make(chan struct{}),
+		refreshRequests: make(chan struct{}, 1),
 	}
 }
+
+// Refresh triggers a full connector data update, keeping track of the refresh status.
+func (p *Provider) Refresh(ctx context.Context) error {
+	// Prevent concurrent connector refreshes from tracing requests
+	p.refreshLock.Lock()
+	defer p.refreshLock.Unlock()
+
+	p.Lock()
+	p.refreshing = true
+	generation := p.RefreshCount + 1
+	p.Unlock()
+
+	startTime := time.Now()
+
+	// Count records emitted by the connector while forwarding them to the filters chain, tagging them with the
+	// refresh generation
+	recordChan := make(chan *catalog.Record)
+	countChan := make(chan int)
+
+	go func() {
+		count := 0
+
+		for record := range recordChan {
+			record.Generation = generation
+			p.Filters.Input <- record
+			count++
+		}
+
+		countChan <- count
+	}()
+
+	err := p.Connector.Refresh(ctx, p.Name, recordChan)
+
+	close(recordChan)
+	count := <-countChan
+
+	// Wait for the emitted records to go through the filters chain and reach the catalog before reconciling its
+	// entries, otherwise metrics whose confirming record is still in flight would be purged or expired
+	flush := catalog.NewFlushRecord()
+	p.Filters.Input <- flush
+	<-flush.Flushed()
+
+	p.Lock()
+	defer p.Unlock()
+
+	p.LastRefreshDuration = time.Since(startTime)
+	p.LastRefreshError = err
+	p.LastRefreshRecords = count
+	p.RefreshCount++
+
+	if err == nil {
+		p.LastRefresh = time.Now()
+
+		// Remove catalog entries restored from snapshot that have not been confirmed by the connector
+		p.Catalog.PurgeStale(p.Connector)
+
+		// Remove catalog entries the connector stopped emitting
+		if p.Config.ExpireDelay > 0 {
+			delay := time.Duration(p.Config.ExpireDelay) * time.Second
+
+			if count := p.Catalog.Expire(p.Connector, generation, delay); count > 0 {
+				logger.Log(logger.LevelInfo, "provider", "expired %d metrics from provider `%s'", count, p.Name)
+			}
+		}
+	}
+
+	// Notify refresh waiters
+	p.refreshing = false
+	close(p.refreshChan)
+	p.refreshChan = make(chan struct{})
+
+	return err
+}
+
+// TraceFilters applies the provider filters chain on records without forwarding them to the catalog, returning the
+// trace of the rules applied on each of them.
+func (p *Provider) TraceFilters(records []*catalog.Record) []*FilterTrace {
+	traces := make([]*FilterTrace, len(records))
+	for i, record := range records {
+		traces[i] = p.Filters.trace(record)
+	}
+
+	return traces
+}
+
+// TraceRefresh performs a refresh of a throwaway connector instance built from the provider configuration, tracing
+// the emitted records through the filters chain. Neither the catalog nor the provider connector state are updated.
+func (p *Provider) TraceRefresh(ctx context.Context) ([]*FilterTrace, error) {
+	connectorType, err := config.GetString(p.Config.Connector, "type", true)
+	if err != nil {
+		return nil, fmt.Errorf("provider `%s' connector: %s", p.Name, err)
+	} else if _, ok := connector.Connectors[connectorType]; !ok {
+		return nil, fmt.Errorf("provider `%s' uses unknown connector type `%s'", p.Name, connectorType)
+	}
+
+	conn, err := connector.Connectors[connectorType](p.Name, p.Config.Connector)
+	if err != nil {
+		return nil, err
+	}
+
+	if closer, ok := conn.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	recordChan := make(chan *catalog.Record)
+	tracesChan := make(chan []*FilterTrace)
+
+	go func() {
+		traces := make([]*FilterTrace, 0)
+		for record := range recordChan {
+			traces = append(traces, p.Filters.trace(record))
+		}
+
+		tracesChan <- traces
+	}()
+
+	err = conn.Refresh(ctx, p.Name, recordChan)
+
+	close(recordChan)
+	traces := <-tracesChan
+
+	return traces, err
+}
+
+// Close shuts down the provider filters chain, waiting for pending records to be forwarded to the catalog, then
+// releases the connector resources if it holds any (e.g. database connections).
+func (p *Provider) Close() {
+	close(p.Filters.Input)
+	<-p.Filters.done
+
+	if closer, ok := p.Connector.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			logger.Log(logger.LevelWarning, "provider", "%s: unable to close connector: %s", p.Name, err)
+		}
+	}
+}
+
+// RequestRefresh requests a provider refresh to be performed, merging with any request still pending.
+func (p *Provider) RequestRefresh() {
+	select {
+	case p.refreshRequests <- struct{}{}:
+	default:
+	}
+}
+
+// RefreshRequests returns a channel receiving the pending refresh requests.
+func (p *Provider) RefreshRequests() <-chan struct{} {
+	return p.refreshRequests
+}
+
+// NextRefresh returns the sequence number of the next refresh to be started.
+func (p *Provider) NextRefresh() int {
+	p.RLock()
+	defer p.RUnlock()
+
+	if p.refreshing {
+		return p.RefreshCount + 2
+	}
+
+	return p.RefreshCount + 1
+}
+
+// WaitRefresh waits for the refresh matching a sequence number to complete.
+func (p *Provider) WaitRefresh(ctx context.Context, seq int) error {
+	for {
+		p.RLock()
+		count, refreshChan := p.RefreshCount, p.refreshChan
+		p.RUnlock()
+
+		if count >= seq {
+			return nil
+		}
+
+		select {
+		case <-refreshChan:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// RecordQuery records the outcome of a plot query performed on the provider connector.
+func (p *Provider) RecordQuery(err error) {
+	p.Lock()
+	defer p.Unlock()
+
+	period := queryStatsPeriod()
+	bucket := &p.queryStats[period%int64(queryStatsBuckets)]
+
+	// Reset bucket if it belongs to an elapsed period
+	if bucket.period != period {
+		*bucket = queryStatsBucket{period: period}
+	}
+
+	bucket.total++
+	if err != nil {
+		bucket.errors++
+	}
+}
+
+// QueryStats returns the number of plot queries and failed ones over the last statistics window.
+func (p *Provider) QueryStats() (int, int) {
+	var total, errors int
+
+	p.RLock()
+	defer p.RUnlock()
+
+	period := queryStatsPeriod()
+
+	for _, bucket := range p.queryStats {
+		if bucket.period > period-int64(queryStatsBuckets) {
+			total += bucket.total
+			errors += bucket.errors
+		}
+	}
+
+	return total, errors
+}
+
+func queryStatsPeriod() int64 {
+	return time.Now().Unix() / int64(QueryStatsWindow.Seconds()/float64(queryStatsBuckets))
+}
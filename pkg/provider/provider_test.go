@@ -0,0 +1,182 @@
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/facette/facette/pkg/catalog"
+	"github.com/facette/facette/pkg/config"
+	"github.com/facette/facette/pkg/connector"
+	"github.com/facette/facette/pkg/plot"
+)
+
+func Test_ProviderRefresh(test *testing.T) {
+	serverCatalog := catalog.NewCatalog()
+
+	go func() {
+		for record := range serverCatalog.RecordChan {
+			serverCatalog.Insert(record)
+		}
+	}()
+
+	prov := NewProvider("test", &config.ProviderConfig{}, serverCatalog)
+	prov.Connector = &testConnector{release: make(chan struct{})}
+
+	seq := prov.NextRefresh()
+
+	done := make(chan error)
+	go func() { done <- prov.WaitRefresh(context.Background(), seq) }()
+
+	go prov.Refresh(context.Background())
+
+	select {
+	case <-done:
+		test.Fatal("refresh wait returned before refresh completion")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(prov.Connector.(*testConnector).release)
+
+	if err := <-done; err != nil {
+		test.Fatal(err)
+	}
+
+	if prov.RefreshCount != 1 || prov.LastRefreshRecords != 3 || prov.LastRefresh.IsZero() {
+		test.Logf("\nExpected 1 refresh with 3 records\nbut got  %d refreshes with %d records", prov.RefreshCount,
+			prov.LastRefreshRecords)
+		test.Fail()
+	}
+}
+
+func Test_ProviderExpire(test *testing.T) {
+	serverCatalog := catalog.NewCatalog()
+
+	// Slow down catalog insertions so that records are still in flight when the connector refresh returns
+	go func() {
+		for record := range serverCatalog.RecordChan {
+			time.Sleep(5 * time.Millisecond)
+			serverCatalog.Insert(record)
+		}
+	}()
+
+	release := make(chan struct{})
+	close(release)
+
+	prov := NewProvider("test", &config.ProviderConfig{ExpireDelay: 1}, serverCatalog)
+	prov.Connector = &testConnector{release: release, sources: []string{"host1", "host2"}}
+
+	if err := prov.Refresh(context.Background()); err != nil {
+		test.Fatal(err)
+	}
+
+	metrics := make(map[string]*catalog.Metric)
+	for _, metricName := range []string{"cpu.idle", "cpu.user", "load.shortterm"} {
+		metric, err := serverCatalog.GetMetric("test", "host2", metricName)
+		if err != nil {
+			test.Fatalf("\nExpected `%s' metric to be present in `host2' source once refreshed", metricName)
+		}
+
+		metrics[metricName] = metric
+	}
+
+	// Stop emitting metrics from the `host1' source, then refresh once grace delay is over
+	prov.Connector.(*testConnector).sources = []string{"host2"}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	if err := prov.Refresh(context.Background()); err != nil {
+		test.Fatal(err)
+	}
+
+	if _, err := serverCatalog.GetSource("test", "host1"); err == nil {
+		test.Logf("\nExpected `host1' source to be expired")
+		test.Fail()
+	}
+
+	// Metrics still emitted must have been kept as is, not expired then inserted again
+	for metricName, expected := range metrics {
+		if actual, err := serverCatalog.GetMetric("test", "host2", metricName); err != nil || actual != expected {
+			test.Logf("\nExpected `%s' metric to be kept in `host2' source\nbut got  %v", metricName, err)
+			test.Fail()
+		}
+	}
+}
+
+func Test_ProviderClose(test *testing.T) {
+	serverCatalog := catalog.NewCatalog()
+
+	prov := NewProvider("test", &config.ProviderConfig{}, serverCatalog)
+	prov.Connector = &testConnector{}
+	prov.Close()
+
+	if !prov.Connector.(*testConnector).closed {
+		test.Logf("\nExpected connector to be closed along with provider")
+		test.Fail()
+	}
+}
+
+func Test_ProviderTraceRefresh(test *testing.T) {
+	var traceConnector *testConnector
+
+	connector.Connectors["test"] = func(name string, settings map[string]interface{}) (connector.Connector, error) {
+		traceConnector = &testConnector{release: make(chan struct{}), sources: []string{"host1", "host2"}}
+		close(traceConnector.release)
+
+		return traceConnector, nil
+	}
+	defer delete(connector.Connectors, "test")
+
+	prov := NewProvider("test", &config.ProviderConfig{
+		Connector: map[string]interface{}{"type": "test"},
+	}, catalog.NewCatalog())
+
+	// Running connector is never released: tracing a refresh through it would block
+	prov.Connector = &testConnector{release: make(chan struct{})}
+
+	traces, err := prov.TraceRefresh(context.Background())
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	if len(traces) != 6 || traceConnector == nil || !traceConnector.closed {
+		test.Logf("\nExpected 6 traces from a closed throwaway connector\nbut got  %d traces", len(traces))
+		test.Fail()
+	}
+}
+
+type testConnector struct {
+	release chan struct{}
+	sources []string
+	closed  bool
+}
+
+func (c *testConnector) GetName() string {
+	return "test"
+}
+
+func (c *testConnector) Close() error {
+	c.closed = true
+	return nil
+}
+
+func (c *testConnector) GetPlots(ctx context.Context, query *plot.Query) ([]*plot.Series, error) {
+	return nil, nil
+}
+
+func (c *testConnector) Refresh(ctx context.Context, originName string, outputChan chan<- *catalog.Record) error {
+	<-c.release
+
+	sources := c.sources
+	if sources == nil {
+		sources = []string{"host1"}
+	}
+
+	for _, sourceName := range sources {
+		for _, metricName := range []string{"cpu.idle", "cpu.user", "load.shortterm"} {
+			outputChan <- &catalog.Record{Origin: originName, Source: sourceName, Metric: metricName, Connector: c}
+		}
+	}
+
+	return nil
+}
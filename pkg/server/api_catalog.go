@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/facette/facette/pkg/library"
+	"github.com/facette/facette/pkg/logger"
 	"github.com/facette/facette/pkg/utils"
 	"github.com/fatih/set"
 )
@@ -22,6 +23,8 @@ func (server *Server) serveCatalog(writer http.ResponseWriter, request *http.Req
 		server.serveSource(writer, request)
 	} else if routeMatch(request.URL.Path, urlCatalogPath+"metrics") {
 		server.serveMetric(writer, request)
+	} else if routeMatch(request.URL.Path, urlCatalogPath+"search") {
+		server.serveCatalogSearch(writer, request)
 	} else {
 		server.serveResponse(writer, nil, http.StatusNotFound)
 	}
@@ -272,3 +275,38 @@ func (server *Server) serveMetricList(writer http.ResponseWriter, request *http.
 
 	server.serveResponse(writer, response.list, http.StatusOK)
 }
+
+func (server *Server) serveCatalogSearch(writer http.ResponseWriter, request *http.Request) {
+	var offset, limit int
+
+	if response, status := server.parseListRequest(writer, request, &offset, &limit); status != http.StatusOK {
+		server.serveResponse(writer, response, status)
+		return
+	} else if strings.TrimSpace(request.FormValue("q")) == "" {
+		server.serveResponse(writer, serverResponse{mesgMissingParameter}, http.StatusBadRequest)
+		return
+	}
+
+	mode := request.FormValue("mode")
+	if mode == "" {
+		mode = catalogSearchFuzzy
+	}
+
+	results, err := searchCatalog(server.Catalog, mode, request.FormValue("q"), request.FormValue("origin"),
+		request.FormValue("source"))
+	if err != nil {
+		logger.Log(logger.LevelWarning, "server", "%s", err)
+		server.serveResponse(writer, serverResponse{mesgRequestInvalid}, http.StatusBadRequest)
+		return
+	}
+
+	response := &listResponse{
+		list:   results,
+		offset: offset,
+		limit:  limit,
+	}
+
+	server.applyResponseLimit(writer, request, response)
+
+	server.serveResponse(writer, response.list, http.StatusOK)
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
@@ -108,6 +109,14 @@ func (server *Server) serveGraph(writer http.ResponseWriter, request *http.Reque
 			return
 		}
 
+		// Check for series expressions validity, reporting the error details to the client
+		if err := graph.Validate(); err != nil {
+			logger.Log(logger.LevelError, "server", "%s", err)
+			server.serveResponse(writer, serverResponse{fmt.Sprintf("%s: %s", mesgResourceInvalid, err)},
+				http.StatusBadRequest)
+			return
+		}
+
 		// Store graph item
 		err := server.Library.StoreItem(graph, library.LibraryItemGraph)
 		if response, status := server.parseError(writer, request, err); status != http.StatusOK {
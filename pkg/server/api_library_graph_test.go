@@ -0,0 +1,69 @@
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/facette/facette/pkg/catalog"
+	"github.com/facette/facette/pkg/library"
+)
+
+func Test_GraphSeriesExprValidation(test *testing.T) {
+	dirPath, err := ioutil.TempDir("", "facette")
+	if err != nil {
+		test.Fatal(err)
+	}
+	defer os.RemoveAll(dirPath)
+
+	server := NewServer("", "", 0)
+	server.Config.DataDir = dirPath
+	server.Catalog = catalog.NewCatalog()
+	server.Library = library.NewLibrary(server.Config, server.Catalog)
+
+	if err := server.Library.Refresh(); err != nil {
+		test.Fatal(err)
+	}
+
+	for i, entry := range []struct {
+		expr    string
+		status  int
+		message string
+	}{
+		{"errors / requests * 100", http.StatusCreated, ""},
+		{"errors / (total - ratio)", http.StatusBadRequest,
+			"Resource is invalid: invalid `ratio' series expression: unknown `ratio' series"},
+		{"errors / series0", http.StatusBadRequest,
+			"Resource is invalid: invalid `ratio' series expression: unknown `series0' series"},
+		{"errors / ", http.StatusBadRequest,
+			"Resource is invalid: invalid `ratio' series expression: unexpected `end of expression' at position 10"},
+	} {
+		body := `{"name": "graph` + strconv.Itoa(i) + `", "groups": [` +
+			`{"name": "group0", "type": 1, "series": [` +
+			`{"name": "errors", "origin": "origin0", "source": "source0", "metric": "errors"},` +
+			`{"name": "requests", "origin": "origin0", "source": "source0", "metric": "requests"},` +
+			`{"name": "ratio", "expr": ` + strconv.Quote(entry.expr) + `}]},` +
+			`{"name": "total", "type": 3, "series": [` +
+			`{"name": "series0", "origin": "origin0", "source": "source0", "metric": "requests"}]}]}`
+
+		request := httptest.NewRequest("POST", urlLibraryPath+"graphs/", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+
+		recorder := httptest.NewRecorder()
+		server.serveGraph(recorder, request)
+
+		response := serverResponse{}
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+
+		if recorder.Code != entry.status || response.Message != entry.message {
+			test.Logf("\nExpected %d status with %q message for %q\nbut got  %d status with %q message",
+				entry.status, entry.message, entry.expr, recorder.Code, response.Message)
+			test.Fail()
+		}
+	}
+}
@@ -1,12 +1,16 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/facette/facette/pkg/config"
@@ -106,19 +110,19 @@ func (server *Server) servePlots(writer http.ResponseWriter, request *http.Reque
 		return
 	}
 
-	plotSeries, err := executeQueries(providerQueries)
+	plotSeries, seriesErrors, err := server.executeQueries(request.Context(), providerQueries)
 	if err != nil {
 		logger.Log(logger.LevelError, "server", "unable to execute provider queries: %s", err)
 		server.serveResponse(writer, serverResponse{mesgProviderQueryError}, http.StatusInternalServerError)
 		return
 	}
 
-	if len(plotSeries) == 0 {
+	if len(plotSeries) == 0 && len(seriesErrors) == 0 {
 		server.serveResponse(writer, serverResponse{mesgEmptyData}, http.StatusOK)
 		return
 	}
 
-	response, err := makePlotsResponse(plotSeries, plotReq, graph)
+	response, err := makePlotsResponse(plotSeries, seriesErrors, plotReq, graph)
 	if err != nil {
 		logger.Log(logger.LevelError, "server", "unable to make plots response: %s", err)
 		server.serveResponse(writer, serverResponse{mesgPlotOperationError}, http.StatusInternalServerError)
@@ -148,6 +152,10 @@ func (server *Server) expandGraphTemplate(graph *library.Graph) error {
 			if series.Metric, err = expandStringTemplate(series.Metric, graph.Attributes); err != nil {
 				return fmt.Errorf("failed to expand graph series %q metric: %s", series.Name, err)
 			}
+
+			if series.Expr, err = expandStringTemplate(series.Expr, graph.Attributes); err != nil {
+				return fmt.Errorf("failed to expand graph series %q expression: %s", series.Name, err)
+			}
 		}
 	}
 
@@ -159,12 +167,35 @@ func (server *Server) prepareProviderQueries(plotReq *PlotRequest,
 
 	providerQueries := make(map[string]*providerQuery)
 
+	step := plotReq.endTime.Sub(plotReq.startTime) / time.Duration(plotReq.Sample)
+
 	for _, groupItem := range graph.Groups {
 		for _, seriesItem := range groupItem.Series {
-			var seriesSources []string
+			var (
+				seriesSources []string
+				leadIn        time.Duration
+				windowPoints  int
+			)
 
 			if seriesItem == nil {
 				return nil, os.ErrNotExist
+			} else if seriesItem.Expr != "" {
+				// Expression series only rely on other series of the graph
+				continue
+			}
+
+			// Fetch data preceding the requested time range for windowed series functions
+			_, window, err := getSeriesWindowFunction(mergeSeriesOptions(groupItem.Options, seriesItem.Options))
+			if err == nil && window != nil {
+				leadIn = window.LeadIn(step)
+				windowPoints = window.Points
+			}
+
+			// Fetch time-shifted series over their own time range, invalid shifts being reported along with plots
+			timeShift, timeShiftLabel, err := getSeriesTimeShift(seriesItem.Options, plotReq.startTime)
+			if err != nil {
+				logger.Log(logger.LevelWarning, "server", "%s", err)
+				continue
 			}
 
 			// Expand source groups
@@ -198,29 +229,51 @@ func (server *Server) prepareProviderQueries(plotReq *PlotRequest,
 						continue
 					}
 
-					// Get provider name
+					// Get provider name, and use a dedicated query per time shift
 					providerName := metric.GetConnector().(connector.Connector).GetName()
 
+					queryKey := providerName
+					if timeShift != 0 {
+						queryKey = fmt.Sprintf("%s@%s", providerName, timeShift)
+					}
+
 					// Initialize provider query if needed
-					if _, ok := providerQueries[providerName]; !ok {
-						providerQueries[providerName] = &providerQuery{
+					if _, ok := providerQueries[queryKey]; !ok {
+						var timeout time.Duration
+
+						if prov, ok := server.getProvider(providerName); ok && prov.Config.QueryTimeout > 0 {
+							timeout = time.Duration(prov.Config.QueryTimeout) * time.Second
+						}
+
+						providerQueries[queryKey] = &providerQuery{
 							query: plot.Query{
 								Requestor: plotReq.requestor,
-								StartTime: plotReq.startTime,
-								EndTime:   plotReq.endTime,
+								StartTime: plotReq.startTime.Add(timeShift),
+								EndTime:   plotReq.endTime.Add(timeShift),
 								Sample:    plotReq.Sample,
 								Series:    make([]plot.QuerySeries, 0),
 							},
 							queryMap:  make([]providerQueryMap, 0),
+							provider:  providerName,
 							connector: metric.GetConnector().(connector.Connector),
+							timeout:   timeout,
+							step:      step,
+							timeShift: timeShift,
 						}
 					}
 
+					// Extend query time range with lead-in data if any
+					providerQueries[queryKey].extendLeadIn(leadIn)
+
+					if windowPoints > providerQueries[queryKey].windowPoints {
+						providerQueries[queryKey].windowPoints = windowPoints
+					}
+
 					// Append metric to provider query
-					providerQueries[providerName].query.Series = append(
-						providerQueries[providerName].query.Series,
+					providerQueries[queryKey].query.Series = append(
+						providerQueries[queryKey].query.Series,
 						plot.QuerySeries{
-							Name:   fmt.Sprintf("series%d", len(providerQueries[providerName].query.Series)),
+							Name:   fmt.Sprintf("series%d", len(providerQueries[queryKey].query.Series)),
 							Origin: metric.GetSource().GetOrigin().OriginalName,
 							Source: metric.GetSource().OriginalName,
 							Metric: metric.OriginalName,
@@ -228,13 +281,14 @@ func (server *Server) prepareProviderQueries(plotReq *PlotRequest,
 					)
 
 					// Keep track of user-defined series name and source/metric information
-					providerQueries[providerName].queryMap = append(
-						providerQueries[providerName].queryMap,
+					providerQueries[queryKey].queryMap = append(
+						providerQueries[queryKey].queryMap,
 						providerQueryMap{
 							seriesName:      seriesItem.Name,
 							sourceName:      metric.GetSource().Name,
 							metricName:      metric.Name,
 							fromSourceGroup: strings.HasPrefix(seriesItem.Source, library.LibraryGroupPrefix),
+							timeShift:       timeShiftLabel,
 						},
 					)
 				}
@@ -245,6 +299,44 @@ func (server *Server) prepareProviderQueries(plotReq *PlotRequest,
 	return providerQueries, nil
 }
 
+// extendLeadIn extends the provider query time range so that it fetches at least the given lead-in data preceding
+// the requested time range, keeping the requested plots resolution. It returns whether or not the query changed.
+func (providerQuery *providerQuery) extendLeadIn(leadIn time.Duration) bool {
+	if leadIn <= providerQuery.leadIn {
+		return false
+	}
+
+	delta := leadIn - providerQuery.leadIn
+
+	providerQuery.query.StartTime = providerQuery.query.StartTime.Add(-delta)
+	providerQuery.leadIn = leadIn
+
+	if providerQuery.step > 0 {
+		providerQuery.query.Sample += int(delta / providerQuery.step)
+	}
+
+	return true
+}
+
+// checkLeadIn checks whether the lead-in data fetched for plots-based windows covers enough plots, as it is estimated
+// from the requested step whereas windows apply on the plots returned by the connector, which can be coarser. The
+// query is then extended according to the actual series step, returning whether or not it has to be performed again.
+func (providerQuery *providerQuery) checkLeadIn(plots []*plot.Series) bool {
+	var step time.Duration
+
+	if providerQuery.windowPoints == 0 {
+		return false
+	}
+
+	for _, series := range plots {
+		if seriesStep := getSeriesStep(series); seriesStep > step {
+			step = seriesStep
+		}
+	}
+
+	return providerQuery.extendLeadIn(time.Duration(providerQuery.windowPoints) * step)
+}
+
 func parsePlotRequest(request *http.Request) (*PlotRequest, error) {
 	var err error
 
@@ -283,18 +375,76 @@ func parsePlotRequest(request *http.Request) (*PlotRequest, error) {
 	return plotReq, nil
 }
 
-func executeQueries(queries map[string]*providerQuery) (map[string][]plot.Series, error) {
+func (server *Server) executeQueries(ctx context.Context, queries map[string]*providerQuery) (map[string][]plot.Series,
+	map[string]error, error) {
+
+	var wg sync.WaitGroup
+
+	workers := server.Config.QueryWorkers
+	if workers <= 0 {
+		workers = config.DefaultQueryWorkers
+	}
+
+	// Sort queries keys to keep series order consistent across requests
+	queryKeys := make([]string, 0)
+	for queryKey := range queries {
+		queryKeys = append(queryKeys, queryKey)
+	}
+
+	sort.Strings(queryKeys)
+
+	// Query providers concurrently, bounding the number of in-flight queries
+	results := make([]providerQueryResult, len(queryKeys))
+	workerChan := make(chan struct{}, workers)
+
+	for i, queryKey := range queryKeys {
+		wg.Add(1)
+
+		go func(result *providerQueryResult, providerName string, providerQuery *providerQuery) {
+			defer wg.Done()
+
+			workerChan <- struct{}{}
+			defer func() { <-workerChan }()
+
+			// Skip query if the request has been cancelled while waiting for a worker
+			if result.err = ctx.Err(); result.err != nil {
+				return
+			}
+
+			result.plots, result.err = server.getProviderPlots(ctx, providerName, providerQuery)
+
+			// Fetch plots again if series are too coarse for the lead-in data to cover plots-based windows
+			if result.err == nil && providerQuery.checkLeadIn(result.plots) {
+				result.plots, result.err = server.getProviderPlots(ctx, providerName, providerQuery)
+			}
+		}(&results[i], queries[queryKey].provider, queries[queryKey])
+	}
+
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
 	plotSeries := make(map[string][]plot.Series)
+	seriesErrors := make(map[string]error)
+
+	for i, queryKey := range queryKeys {
+		providerQuery := queries[queryKey]
+
+		if results[i].err != nil {
+			logger.Log(logger.LevelError, "server", "%s", results[i].err)
+
+			// Report failure for all the series relying on this provider
+			for _, queryMap := range providerQuery.queryMap {
+				seriesErrors[queryMap.seriesName] = results[i].err
+			}
 
-	for _, providerQuery := range queries {
-		plots, err := providerQuery.connector.GetPlots(&providerQuery.query)
-		if err != nil {
-			logger.Log(logger.LevelError, "server", "%s", err)
 			continue
 		}
 
 		// Re-arrange internal plot results according to original queries
-		for plotsIndex, plotsItem := range plots {
+		for plotsIndex, plotsItem := range results[i].plots {
 			// Add metric name detail to series name is a source/metric group
 			if providerQuery.queryMap[plotsIndex].fromSourceGroup ||
 				strings.HasPrefix(providerQuery.queryMap[plotsIndex].seriesName, library.LibraryGroupPrefix) {
@@ -308,6 +458,12 @@ func executeQueries(queries map[string]*providerQuery) (map[string][]plot.Series
 				plotsItem.Name = providerQuery.queryMap[plotsIndex].seriesName
 			}
 
+			// Move time-shifted plots back onto the requested time range
+			if providerQuery.timeShift != 0 {
+				plotsItem.Shift(-providerQuery.timeShift)
+				plotsItem.Name = fmt.Sprintf("%s (%s)", plotsItem.Name, providerQuery.queryMap[plotsIndex].timeShift)
+			}
+
 			if _, ok := plotSeries[providerQuery.queryMap[plotsIndex].seriesName]; !ok {
 				plotSeries[providerQuery.queryMap[plotsIndex].seriesName] = make([]plot.Series, 0)
 			}
@@ -319,10 +475,41 @@ func executeQueries(queries map[string]*providerQuery) (map[string][]plot.Series
 		}
 	}
 
-	return plotSeries, nil
+	return plotSeries, seriesErrors, nil
+}
+
+func (server *Server) getProviderPlots(ctx context.Context, providerName string,
+	providerQuery *providerQuery) ([]*plot.Series, error) {
+
+	fetch := func(ctx context.Context, query *plot.Query) ([]*plot.Series, error) {
+		queryCtx := ctx
+
+		// Apply provider query deadline if any
+		if providerQuery.timeout > 0 {
+			var cancel context.CancelFunc
+
+			queryCtx, cancel = context.WithTimeout(ctx, providerQuery.timeout)
+			defer cancel()
+		}
+
+		plots, err := providerQuery.connector.GetPlots(queryCtx, query)
+
+		// Keep track of provider queries outcome, unless the request has been cancelled by the client
+		if prov, ok := server.getProvider(providerName); ok && ctx.Err() == nil {
+			prov.RecordQuery(err)
+		}
+
+		return plots, err
+	}
+
+	if server.plotCache == nil {
+		return fetch(ctx, &providerQuery.query)
+	}
+
+	return server.plotCache.getPlots(ctx, providerName, providerQuery.query, fetch)
 }
 
-func makePlotsResponse(plotSeries map[string][]plot.Series, plotReq *PlotRequest,
+func makePlotsResponse(plotSeries map[string][]plot.Series, seriesErrors map[string]error, plotReq *PlotRequest,
 	graph *library.Graph) (*PlotResponse, error) {
 
 	response := &PlotResponse{
@@ -339,46 +526,94 @@ func makePlotsResponse(plotSeries map[string][]plot.Series, plotReq *PlotRequest
 		Modified:    graph.Modified,
 	}
 
-	for _, groupItem := range graph.Groups {
+	groupResponses := make([][]*SeriesResponse, len(graph.Groups))
+	namedSeries := make(map[string]plot.Series)
+
+	for groupIndex, groupItem := range graph.Groups {
 		var (
 			groupConsolidate int
 			groupSeries      []plot.Series
+			seriesNames      []string
 			err              error
 		)
 
 		seriesOptions := make(map[string]map[string]interface{})
 
 		for _, seriesItem := range groupItem.Series {
+			// Skip expression series, evaluated once all the other series are available
+			if seriesItem.Expr != "" {
+				continue
+			}
+
+			// Report series for which provider query failed
+			if err, ok := seriesErrors[seriesItem.Name]; ok {
+				response.Errors = append(response.Errors, &SeriesError{Name: seriesItem.Name, Error: err.Error()})
+			}
+
+			// Report series with invalid time shift, skipped when preparing provider queries
+			if _, _, err := getSeriesTimeShift(seriesItem.Options, plotReq.startTime); err != nil {
+				response.Errors = append(response.Errors, &SeriesError{Name: seriesItem.Name, Error: err.Error()})
+				continue
+			}
+
 			if _, ok := plotSeries[seriesItem.Name]; !ok {
+				if _, ok := seriesErrors[seriesItem.Name]; ok {
+					continue
+				}
+
 				return nil, fmt.Errorf("unable to find plots for `%s' series", seriesItem.Name)
 			}
 
+			seriesFunc, err := getSeriesFunction(seriesItem.Options)
+			if err != nil {
+				response.Errors = append(response.Errors, &SeriesError{Name: seriesItem.Name, Error: err.Error()})
+				continue
+			}
+
+			windowFunc, _, err := getSeriesWindowFunction(mergeSeriesOptions(groupItem.Options, seriesItem.Options))
+			if err != nil {
+				response.Errors = append(response.Errors, &SeriesError{Name: seriesItem.Name, Error: err.Error()})
+				continue
+			}
+
 			for _, plotItem := range plotSeries[seriesItem.Name] {
 				var optionKey string
 
+				// Apply series functions if any, then discard lead-in data
+				if seriesFunc != nil {
+					seriesFunc(&plotItem)
+				}
+
+				if windowFunc != nil {
+					windowFunc(&plotItem)
+				}
+
+				plotItem.TrimBefore(plotReq.startTime)
+
 				// Apply series scale if any
 				if scale, _ := config.GetFloat(seriesItem.Options, "scale", false); scale != 0 {
 					plotItem.Scale(plot.Value(scale))
 				}
 
 				// Merge options from group and series
-				if groupItem.Type == plot.OperTypeAverage || groupItem.Type == plot.OperTypeSum {
+				if isGroupOperation(groupItem.Type) {
 					optionKey = groupItem.Name
 				} else {
-					optionKey = seriesItem.Name
+					optionKey = plotItem.Name
 				}
 
 				seriesOptions[optionKey] = make(map[string]interface{})
 				for key, value := range groupItem.Options {
 					seriesOptions[optionKey][key] = value
 				}
-				if groupItem.Type != plot.OperTypeAverage && groupItem.Type != plot.OperTypeSum {
+				if !isGroupOperation(groupItem.Type) {
 					for key, value := range seriesItem.Options {
 						seriesOptions[optionKey][key] = value
 					}
 				}
 
 				groupSeries = append(groupSeries, plotItem)
+				seriesNames = append(seriesNames, seriesItem.Name)
 			}
 		}
 
@@ -404,22 +639,11 @@ func makePlotsResponse(plotSeries map[string][]plot.Series, plotReq *PlotRequest
 		}
 
 		// Perform requested series operations
-		if groupItem.Type == plot.OperTypeAverage || groupItem.Type == plot.OperTypeSum {
-			var (
-				operSeries plot.Series
-				err        error
-			)
-
-			if groupItem.Type == plot.OperTypeAverage {
-				operSeries, err = plot.AverageSeries(groupSeries)
-				if err != nil {
-					return nil, fmt.Errorf("unable to average series: %s", err)
-				}
-			} else {
-				operSeries, err = plot.SumSeries(groupSeries)
-				if err != nil {
-					return nil, fmt.Errorf("unable to sum series: %s", err)
-				}
+		if isGroupOperation(groupItem.Type) {
+			operSeries, err := operGroupSeries(groupItem, groupSeries)
+			if err != nil {
+				response.Errors = append(response.Errors, &SeriesError{Name: groupItem.Name, Error: err.Error()})
+				continue
 			}
 
 			operSeries.Name = groupItem.Name
@@ -434,11 +658,39 @@ func makePlotsResponse(plotSeries map[string][]plot.Series, plotReq *PlotRequest
 			}
 		}
 
-		for _, seriesItem := range groupSeries {
-			// Summarize each series (compute min/max/avg/last values)
-			seriesItem.Summarize(plotReq.Percentiles)
+		// Summarize each series (compute min/max/avg/last values)
+		for i := range groupSeries {
+			groupSeries[i].Summarize(plotReq.Percentiles)
+		}
+
+		// Register series for expressions evaluation, also using their definition name if not expanded
+		expanded := make(map[string]int)
+		for _, name := range seriesNames {
+			expanded[name]++
+		}
+
+		for i, seriesItem := range groupSeries {
+			if _, ok := namedSeries[seriesItem.Name]; !ok {
+				namedSeries[seriesItem.Name] = seriesItem
+			}
+
+			if _, ok := namedSeries[seriesNames[i]]; !ok && !isGroupOperation(groupItem.Type) &&
+				expanded[seriesNames[i]] == 1 {
 
-			response.Series = append(response.Series, &SeriesResponse{
+				namedSeries[seriesNames[i]] = seriesItem
+			}
+		}
+
+		// Only keep top or bottom ranked series if requested
+		if !isGroupOperation(groupItem.Type) {
+			if groupSeries, err = selectGroupSeries(groupItem, groupSeries, plotReq.Percentiles); err != nil {
+				response.Errors = append(response.Errors, &SeriesError{Name: groupItem.Name, Error: err.Error()})
+				continue
+			}
+		}
+
+		for _, seriesItem := range groupSeries {
+			groupResponses[groupIndex] = append(groupResponses[groupIndex], &SeriesResponse{
 				Name:    seriesItem.Name,
 				StackID: groupItem.StackID,
 				Plots:   seriesItem.Plots,
@@ -448,5 +700,290 @@ func makePlotsResponse(plotSeries map[string][]plot.Series, plotReq *PlotRequest
 		}
 	}
 
+	// Evaluate expression series in definition order, appending them to their group series
+	for groupIndex, groupItem := range graph.Groups {
+		for _, seriesItem := range groupItem.Series {
+			if seriesItem.Expr == "" {
+				continue
+			}
+
+			exprSeries, err := evalSeriesExpr(groupItem, seriesItem, namedSeries, plotReq.Percentiles)
+			if err != nil {
+				response.Errors = append(response.Errors, &SeriesError{Name: seriesItem.Name, Error: err.Error()})
+				continue
+			}
+
+			namedSeries[seriesItem.Name] = exprSeries
+
+			groupResponses[groupIndex] = append(groupResponses[groupIndex], &SeriesResponse{
+				Name:    exprSeries.Name,
+				StackID: groupItem.StackID,
+				Plots:   exprSeries.Plots,
+				Summary: exprSeries.Summary,
+				Options: mergeSeriesOptions(groupItem.Options, seriesItem.Options),
+			})
+		}
+	}
+
+	for _, groupResponse := range groupResponses {
+		response.Series = append(response.Series, groupResponse...)
+	}
+
 	return response, nil
 }
+
+func evalSeriesExpr(groupItem *library.OperGroup, seriesItem *library.Series, namedSeries map[string]plot.Series,
+	percentiles []float64) (plot.Series, error) {
+
+	if groupItem.Type != plot.OperTypeNone {
+		return plot.Series{}, fmt.Errorf("expression series can't be part of an operation group")
+	}
+
+	expr, err := plot.ParseExpr(seriesItem.Expr)
+	if err != nil {
+		return plot.Series{}, err
+	}
+
+	series, err := expr.Eval(namedSeries)
+	if err != nil {
+		return plot.Series{}, err
+	}
+
+	series.Name = seriesItem.Name
+
+	// Apply series and group scales if any
+	if scale, _ := config.GetFloat(seriesItem.Options, "scale", false); scale != 0 {
+		series.Scale(plot.Value(scale))
+	}
+
+	if scale, _ := config.GetFloat(groupItem.Options, "scale", false); scale != 0 {
+		series.Scale(plot.Value(scale))
+	}
+
+	series.Summarize(percentiles)
+
+	return series, nil
+}
+
+func isGroupOperation(operType int) bool {
+	switch operType {
+	case plot.OperTypeAverage, plot.OperTypeSum, plot.OperTypeMin, plot.OperTypeMax, plot.OperTypeMedian,
+		plot.OperTypePercentile, plot.OperTypeStddev, plot.OperTypeCount:
+		return true
+	}
+
+	return false
+}
+
+func operGroupSeries(groupItem *library.OperGroup, groupSeries []plot.Series) (plot.Series, error) {
+	switch groupItem.Type {
+	case plot.OperTypeAverage:
+		return plot.AverageSeries(groupSeries)
+
+	case plot.OperTypeSum:
+		return plot.SumSeries(groupSeries)
+
+	case plot.OperTypeMin:
+		return plot.MinSeries(groupSeries)
+
+	case plot.OperTypeMax:
+		return plot.MaxSeries(groupSeries)
+
+	case plot.OperTypeMedian:
+		return plot.MedianSeries(groupSeries)
+
+	case plot.OperTypePercentile:
+		percentile, err := config.GetFloat(groupItem.Options, "percentile", true)
+		if err != nil {
+			return plot.Series{}, err
+		}
+
+		return plot.PercentileSeries(groupSeries, percentile)
+
+	case plot.OperTypeStddev:
+		return plot.StddevSeries(groupSeries)
+
+	case plot.OperTypeCount:
+		return plot.CountSeries(groupSeries)
+	}
+
+	return plot.Series{}, fmt.Errorf("unsupported group operation %d", groupItem.Type)
+}
+
+func selectGroupSeries(groupItem *library.OperGroup, groupSeries []plot.Series,
+	percentiles []float64) ([]plot.Series, error) {
+
+	top, err := config.GetInt(groupItem.Options, "top", false)
+	if err != nil {
+		return nil, err
+	}
+
+	bottom, err := config.GetInt(groupItem.Options, "bottom", false)
+	if err != nil {
+		return nil, err
+	}
+
+	if top == 0 && bottom == 0 {
+		return groupSeries, nil
+	} else if top != 0 && bottom != 0 {
+		return nil, fmt.Errorf("top and bottom settings are mutually exclusive")
+	} else if top < 0 || bottom < 0 {
+		return nil, fmt.Errorf("top and bottom settings must be greater than zero")
+	}
+
+	rankBy, err := config.GetString(groupItem.Options, "rank_by", false)
+	if err != nil {
+		return nil, err
+	}
+
+	if rankBy == "" {
+		rankBy = "avg"
+	} else if rankBy != "min" && rankBy != "max" && rankBy != "avg" && rankBy != "last" {
+		// Compute ranking percentile if not part of the requested ones
+		percentile, err := strconv.ParseFloat(strings.TrimSuffix(rankBy, "th"), 64)
+		if err != nil || !strings.HasSuffix(rankBy, "th") || percentile < 0 || percentile > 100 {
+			return nil, fmt.Errorf("invalid `%s' rank value", rankBy)
+		}
+
+		rankBy = fmt.Sprintf("%gth", percentile)
+
+		for i := range groupSeries {
+			if _, ok := groupSeries[i].Summary[rankBy]; !ok {
+				groupSeries[i].Percentiles([]float64{percentile})
+			}
+		}
+	}
+
+	other, err := config.GetBool(groupItem.Options, "other", false)
+	if err != nil {
+		return nil, err
+	}
+
+	selected, rest := plot.SelectSeries(groupSeries, rankBy, top+bottom, top > 0)
+
+	// Collapse remaining series into a single one summing them if requested
+	if other && len(rest) > 0 {
+		otherSeries, err := plot.SumSeries(rest)
+		if err != nil {
+			return nil, err
+		}
+
+		otherSeries.Name = "other"
+		otherSeries.Summarize(percentiles)
+
+		selected = append(selected, otherSeries)
+	}
+
+	return selected, nil
+}
+
+func getSeriesFunction(options map[string]interface{}) (func(*plot.Series), error) {
+	function, err := config.GetString(options, "function", false)
+	if err != nil {
+		return nil, err
+	}
+
+	switch function {
+	case "":
+		return nil, nil
+
+	case "derivative":
+		return (*plot.Series).Derivative, nil
+
+	case "rate":
+		return (*plot.Series).Rate, nil
+
+	case "non_negative_derivative":
+		maxValue, err := config.GetFloat(options, "max_value", false)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(series *plot.Series) { series.NonNegativeDerivative(plot.Value(maxValue)) }, nil
+
+	case "integral":
+		return (*plot.Series).Integral, nil
+	}
+
+	return nil, fmt.Errorf("unknown `%s' series function", function)
+}
+
+func getSeriesTimeShift(options map[string]interface{}, refTime time.Time) (time.Duration, string, error) {
+	value, err := config.GetString(options, "time_shift", false)
+	if err != nil || value == "" {
+		return 0, "", err
+	}
+
+	shiftTime, err := utils.TimeApplyRange(refTime, value)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid time shift `%s'", value)
+	}
+
+	return shiftTime.Sub(refTime), strings.TrimSpace(value), nil
+}
+
+func getSeriesWindowFunction(options map[string]interface{}) (func(*plot.Series), *plot.Window, error) {
+	function, err := config.GetString(options, "window_function", false)
+	if err != nil || function == "" {
+		return nil, nil, err
+	}
+
+	value, ok := options["window"]
+	if !ok {
+		return nil, nil, fmt.Errorf("missing window for `%s' series function", function)
+	}
+
+	window, err := plot.ParseWindow(value)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch function {
+	case "moving_average":
+		return func(series *plot.Series) { series.MovingAverage(window) }, &window, nil
+
+	case "moving_median":
+		return func(series *plot.Series) { series.MovingMedian(window) }, &window, nil
+
+	case "moving_min":
+		return func(series *plot.Series) { series.MovingMin(window) }, &window, nil
+
+	case "moving_max":
+		return func(series *plot.Series) { series.MovingMax(window) }, &window, nil
+
+	case "moving_stddev":
+		return func(series *plot.Series) { series.MovingStddev(window) }, &window, nil
+
+	case "ewma":
+		return func(series *plot.Series) { series.EWMA(window) }, &window, nil
+	}
+
+	return nil, nil, fmt.Errorf("unknown `%s' series window function", function)
+}
+
+func getSeriesStep(series *plot.Series) time.Duration {
+	if series.Step > 0 {
+		return time.Duration(series.Step) * time.Second
+	}
+
+	// Estimate step from plots spacing if not reported by the connector
+	if len(series.Plots) < 2 {
+		return 0
+	}
+
+	return series.Plots[len(series.Plots)-1].Time.Sub(series.Plots[0].Time) / time.Duration(len(series.Plots)-1)
+}
+
+func mergeSeriesOptions(groupOptions, seriesOptions map[string]interface{}) map[string]interface{} {
+	options := make(map[string]interface{})
+
+	for key, value := range groupOptions {
+		options[key] = value
+	}
+
+	for key, value := range seriesOptions {
+		options[key] = value
+	}
+
+	return options
+}
@@ -0,0 +1,609 @@
+// +build synthetic
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/facette/facette/pkg/catalog"
+	"github.com/facette/facette/pkg/connector"
+	"github.com/facette/facette/pkg/library"
+	"github.com/facette/facette/pkg/plot"
+)
+
+func Test_PlotsSumGroup(test *testing.T) {
+	server := newTestPlotServer(test)
+
+	plotReq := &PlotRequest{
+		Sample:    30,
+		startTime: time.Unix(1000020, 0),
+		endTime:   time.Unix(1003620, 0),
+	}
+
+	graph := &library.Graph{
+		Item: library.Item{ID: "graph0", Name: "graph0"},
+		Groups: []*library.OperGroup{
+			{
+				Name: "group0",
+				Type: plot.OperTypeNone,
+				Series: []*library.Series{
+					{Name: "series0", Origin: "synthetic", Source: "host1", Metric: "cpu.idle"},
+					{Name: "series1", Origin: "synthetic", Source: "host2", Metric: "cpu.idle"},
+				},
+			},
+			{
+				Name: "group1",
+				Type: plot.OperTypeSum,
+				Series: []*library.Series{
+					{Name: "series2", Origin: "synthetic", Source: "host1", Metric: "cpu.idle"},
+					{Name: "series3", Origin: "synthetic", Source: "host2", Metric: "cpu.idle"},
+				},
+			},
+		},
+	}
+
+	response := executeTestPlotRequest(test, server, plotReq, graph)
+
+	if len(response.Series) != 3 {
+		test.Fatalf("\nExpected 3 series\nbut got  %d", len(response.Series))
+	}
+
+	for i, name := range []string{"series0", "series1", "group1"} {
+		if response.Series[i].Name != name || len(response.Series[i].Plots) != plotReq.Sample {
+			test.Logf("\nExpected series `%s' with %d plots\nbut got  `%s' with %d plots", name, plotReq.Sample,
+				response.Series[i].Name, len(response.Series[i].Plots))
+			test.Fail()
+		}
+	}
+
+	for i, p := range response.Series[2].Plots {
+		expected := response.Series[0].Plots[i].Value + response.Series[1].Plots[i].Value
+		if math.Abs(float64(p.Value-expected)) > 1e-9 {
+			test.Logf("\nExpected %g\nbut got  %g", expected, p.Value)
+			test.Fail()
+			break
+		}
+	}
+}
+
+func Test_PlotsGroupOperations(test *testing.T) {
+	server := newTestPlotServer(test)
+
+	plotReq := &PlotRequest{
+		Sample:    30,
+		startTime: time.Unix(1000020, 0),
+		endTime:   time.Unix(1003620, 0),
+	}
+
+	newGroup := func(name string, operType int, options map[string]interface{}) *library.OperGroup {
+		return &library.OperGroup{
+			Name:    name,
+			Type:    operType,
+			Options: options,
+			Series: []*library.Series{
+				{Name: name + "-series0", Origin: "synthetic", Source: "host1", Metric: "cpu.idle"},
+				{Name: name + "-series1", Origin: "synthetic", Source: "host2", Metric: "cpu.idle"},
+			},
+		}
+	}
+
+	graph := &library.Graph{
+		Item: library.Item{ID: "graph0", Name: "graph0"},
+		Groups: []*library.OperGroup{
+			newGroup("min", plot.OperTypeMin, nil),
+			newGroup("max", plot.OperTypeMax, nil),
+			newGroup("median", plot.OperTypeMedian, nil),
+			newGroup("percentile", plot.OperTypePercentile, map[string]interface{}{"percentile": 0.0}),
+			newGroup("count", plot.OperTypeCount, nil),
+			newGroup("invalid", plot.OperTypePercentile, nil),
+		},
+	}
+
+	response := executeTestPlotRequest(test, server, plotReq, graph)
+
+	if len(response.Series) != 5 {
+		test.Fatalf("\nExpected %d series\nbut got  %d", 5, len(response.Series))
+	}
+
+	min, max, median, percentile, count := response.Series[0], response.Series[1], response.Series[2],
+		response.Series[3], response.Series[4]
+
+	for i := range min.Plots {
+		if min.Plots[i].Value > max.Plots[i].Value || median.Plots[i].Value < min.Plots[i].Value ||
+			median.Plots[i].Value > max.Plots[i].Value || percentile.Plots[i].Value != min.Plots[i].Value ||
+			count.Plots[i].Value != 2 {
+
+			test.Logf("\nExpected consistent group operations results\nbut got  min=%g max=%g median=%g "+
+				"percentile=%g count=%g", min.Plots[i].Value, max.Plots[i].Value, median.Plots[i].Value,
+				percentile.Plots[i].Value, count.Plots[i].Value)
+			test.Fail()
+			break
+		}
+	}
+
+	expected := []*SeriesError{{Name: "invalid", Error: "missing mandatory setting `percentile'"}}
+
+	if !reflect.DeepEqual(expected, response.Errors) {
+		test.Logf("\nExpected %v\nbut got  %v", expected, response.Errors)
+		test.Fail()
+	}
+}
+
+func Test_PlotsGroupSelection(test *testing.T) {
+	server := newTestPlotServer(test)
+
+	plotReq := &PlotRequest{
+		Sample:    30,
+		startTime: time.Unix(1000020, 0),
+		endTime:   time.Unix(1003620, 0),
+	}
+
+	newGroup := func(name string, options map[string]interface{}) *library.OperGroup {
+		return &library.OperGroup{
+			Name:    name,
+			Type:    plot.OperTypeNone,
+			Options: options,
+			Series: []*library.Series{
+				{Name: name + "-series0", Origin: "synthetic", Source: "host1", Metric: "net.packets"},
+				{Name: name + "-series1", Origin: "synthetic", Source: "host2", Metric: "net.packets"},
+				{Name: name + "-series2", Origin: "synthetic", Source: "host1", Metric: "cpu.idle"},
+			},
+		}
+	}
+
+	graph := &library.Graph{
+		Item: library.Item{ID: "graph0", Name: "graph0"},
+		Groups: []*library.OperGroup{
+			newGroup("group0", map[string]interface{}{"top": 1.0, "rank_by": "last", "other": true}),
+			newGroup("group1", map[string]interface{}{"bottom": 1.0, "rank_by": "90th"}),
+			newGroup("group2", map[string]interface{}{"top": 1.0, "rank_by": "foo"}),
+		},
+	}
+
+	response := executeTestPlotRequest(test, server, plotReq, graph)
+
+	if len(response.Series) != 3 {
+		test.Fatalf("\nExpected %d series\nbut got  %d", 3, len(response.Series))
+	}
+
+	// Counters are way above CPU idle percentage
+	top, other, bottom := response.Series[0], response.Series[1], response.Series[2]
+
+	if !strings.HasPrefix(top.Name, "group0-series") || top.Name == "group0-series2" {
+		test.Logf("\nExpected top series to be a counter\nbut got  `%s'", top.Name)
+		test.Fail()
+	}
+
+	if other.Name != "other" || len(other.Plots) != plotReq.Sample {
+		test.Logf("\nExpected `other' series with %d plots\nbut got  `%s' with %d plots", plotReq.Sample,
+			other.Name, len(other.Plots))
+		test.Fail()
+	}
+
+	if bottom.Name != "group1-series2" || bottom.Summary["90th"].IsNaN() {
+		test.Logf("\nExpected bottom series `group1-series2' ranked by 90th percentile\nbut got  `%s' (%v)",
+			bottom.Name, bottom.Summary)
+		test.Fail()
+	}
+
+	expected := []*SeriesError{{Name: "group2", Error: "invalid `foo' rank value"}}
+
+	if !reflect.DeepEqual(expected, response.Errors) {
+		test.Logf("\nExpected %v\nbut got  %v", expected, response.Errors)
+		test.Fail()
+	}
+}
+
+func Test_PlotsPartialFailure(test *testing.T) {
+	server := newTestPlotServer(test)
+
+	server.Catalog.Insert(&catalog.Record{
+		Origin:         "failing",
+		Source:         "host1",
+		Metric:         "cpu.idle",
+		OriginalOrigin: "failing",
+		OriginalSource: "host1",
+		OriginalMetric: "cpu.idle",
+		Connector:      failingTestConnector{},
+	})
+
+	plotReq := &PlotRequest{
+		Sample:    30,
+		startTime: time.Unix(1000020, 0),
+		endTime:   time.Unix(1003620, 0),
+	}
+
+	graph := &library.Graph{
+		Item: library.Item{ID: "graph0", Name: "graph0"},
+		Groups: []*library.OperGroup{
+			{
+				Name: "group0",
+				Type: plot.OperTypeNone,
+				Series: []*library.Series{
+					{Name: "series0", Origin: "synthetic", Source: "host1", Metric: "cpu.idle"},
+					{Name: "series1", Origin: "failing", Source: "host1", Metric: "cpu.idle"},
+				},
+			},
+		},
+	}
+
+	response := executeTestPlotRequest(test, server, plotReq, graph)
+
+	if len(response.Series) != 1 || response.Series[0].Name != "series0" {
+		test.Logf("\nExpected series `series0'\nbut got  %d series", len(response.Series))
+		test.Fail()
+	}
+
+	expected := []*SeriesError{{Name: "series1", Error: "failing: backend unavailable"}}
+
+	if !reflect.DeepEqual(expected, response.Errors) {
+		test.Logf("\nExpected %v\nbut got  %v", expected, response.Errors)
+		test.Fail()
+	}
+}
+
+func Test_PlotsSeriesFunction(test *testing.T) {
+	server := newTestPlotServer(test)
+
+	plotReq := &PlotRequest{
+		Sample:    30,
+		startTime: time.Unix(1000020, 0),
+		endTime:   time.Unix(1003620, 0),
+	}
+
+	graph := &library.Graph{
+		Item: library.Item{ID: "graph0", Name: "graph0"},
+		Groups: []*library.OperGroup{
+			{
+				Name: "group0",
+				Type: plot.OperTypeNone,
+				Series: []*library.Series{
+					{Name: "series0", Origin: "synthetic", Source: "host1", Metric: "net.packets",
+						Options: map[string]interface{}{"function": "non_negative_derivative"}},
+					{Name: "series1", Origin: "synthetic", Source: "host1", Metric: "net.packets",
+						Options: map[string]interface{}{"function": "unknown"}},
+				},
+			},
+		},
+	}
+
+	response := executeTestPlotRequest(test, server, plotReq, graph)
+
+	if len(response.Series) != 1 || response.Series[0].Name != "series0" {
+		test.Fatalf("\nExpected series `series0'\nbut got  %d series", len(response.Series))
+	}
+
+	count := 0
+	for _, p := range response.Series[0].Plots {
+		if p.Value.IsNaN() {
+			continue
+		} else if p.Value < 0 {
+			test.Logf("\nExpected non-negative values\nbut got  %g", p.Value)
+			test.Fail()
+			break
+		}
+
+		count++
+	}
+
+	if count == 0 {
+		test.Logf("\nExpected defined values in `series0' series")
+		test.Fail()
+	}
+
+	expected := []*SeriesError{{Name: "series1", Error: "unknown `unknown' series function"}}
+
+	if !reflect.DeepEqual(expected, response.Errors) {
+		test.Logf("\nExpected %v\nbut got  %v", expected, response.Errors)
+		test.Fail()
+	}
+}
+
+func Test_PlotsSeriesWindowFunction(test *testing.T) {
+	server := newTestPlotServer(test)
+
+	plotReq := &PlotRequest{
+		Sample:    30,
+		startTime: time.Unix(1000020, 0),
+		endTime:   time.Unix(1003620, 0),
+	}
+
+	graph := &library.Graph{
+		Item: library.Item{ID: "graph0", Name: "graph0"},
+		Groups: []*library.OperGroup{
+			{
+				Name:    "group0",
+				Type:    plot.OperTypeNone,
+				Options: map[string]interface{}{"window_function": "moving_average", "window": "10m"},
+				Series: []*library.Series{
+					{Name: "series0", Origin: "synthetic", Source: "host1", Metric: "net.packets"},
+					{Name: "series1", Origin: "synthetic", Source: "host1", Metric: "net.packets",
+						Options: map[string]interface{}{"window": "foo"}},
+					{Name: "series2", Origin: "synthetic", Source: "host1", Metric: "cpu.idle",
+						Options: map[string]interface{}{"window_function": ""}},
+					{Name: "series3", Origin: "synthetic", Source: "host1", Metric: "cpu.idle"},
+				},
+			},
+		},
+	}
+
+	response := executeTestPlotRequest(test, server, plotReq, graph)
+
+	if len(response.Series) != 3 {
+		test.Fatalf("\nExpected %d series\nbut got  %d", 3, len(response.Series))
+	}
+
+	// Lead-in data must have been fetched and trimmed
+	plots := response.Series[0].Plots
+	if len(plots) == 0 || plots[0].Time.Before(plotReq.startTime) || plots[0].Value.IsNaN() {
+		test.Logf("\nExpected first plot to be defined and within requested range\nbut got  %v", plots)
+		test.Fail()
+	}
+
+	raw, averaged := response.Series[1].Plots, response.Series[2].Plots
+	if len(raw) != len(averaged) || len(raw) == 0 || raw[0].Value == averaged[0].Value {
+		test.Logf("\nExpected first averaged plot to differ from raw one\nbut got  %v and %v", raw, averaged)
+		test.Fail()
+	}
+
+	expected := []*SeriesError{{Name: "series1", Error: "invalid window `foo'"}}
+
+	if !reflect.DeepEqual(expected, response.Errors) {
+		test.Logf("\nExpected %v\nbut got  %v", expected, response.Errors)
+		test.Fail()
+	}
+}
+
+func Test_PlotsSeriesWindowLeadIn(test *testing.T) {
+	server := newTestPlotServer(test)
+
+	// Request a 9s step whereas synthetic series have a 60s step
+	plotReq := &PlotRequest{
+		Sample:    400,
+		startTime: time.Unix(1000020, 0),
+		endTime:   time.Unix(1003620, 0),
+	}
+
+	graph := &library.Graph{
+		Item: library.Item{ID: "graph0", Name: "graph0"},
+		Groups: []*library.OperGroup{
+			{
+				Name:    "group0",
+				Type:    plot.OperTypeNone,
+				Options: map[string]interface{}{"window_function": "moving_average", "window": 10.0},
+				Series: []*library.Series{
+					{Name: "series0", Origin: "synthetic", Source: "host1", Metric: "cpu.idle"},
+				},
+			},
+		},
+	}
+
+	providerQueries, err := server.prepareProviderQueries(plotReq, graph)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	if _, _, err := server.executeQueries(context.Background(), providerQueries); err != nil {
+		test.Fatal(err)
+	}
+
+	// Lead-in data must cover 10 plots of the series rather than 10 requested steps
+	expected := plotReq.startTime.Add(-10 * time.Minute)
+
+	if actual := providerQueries["synthetic"].query.StartTime; !actual.Equal(expected) {
+		test.Logf("\nExpected query to start at %s\nbut got  %s", expected, actual)
+		test.Fail()
+	}
+}
+
+func Test_PlotsSeriesTimeShift(test *testing.T) {
+	server := newTestPlotServer(test)
+
+	newGraph := func(options map[string]interface{}) *library.Graph {
+		return &library.Graph{
+			Item: library.Item{ID: "graph0", Name: "graph0"},
+			Groups: []*library.OperGroup{
+				{
+					Name: "group0",
+					Type: plot.OperTypeNone,
+					Series: []*library.Series{
+						{Name: "series0", Origin: "synthetic", Source: "host1", Metric: "cpu.idle",
+							Options: options},
+						{Name: "series1", Origin: "synthetic", Source: "host1", Metric: "cpu.idle",
+							Options: map[string]interface{}{"time_shift": "foo"}},
+					},
+				},
+			},
+		}
+	}
+
+	// Request previous hour without shift, then current hour shifted back by one hour
+	previous := executeTestPlotRequest(test, server, &PlotRequest{
+		Sample:    30,
+		startTime: time.Unix(1000020, 0),
+		endTime:   time.Unix(1003620, 0),
+	}, newGraph(nil))
+
+	plotReq := &PlotRequest{
+		Sample:    30,
+		startTime: time.Unix(1003620, 0),
+		endTime:   time.Unix(1007220, 0),
+	}
+
+	response := executeTestPlotRequest(test, server, plotReq, newGraph(map[string]interface{}{"time_shift": "-1h"}))
+
+	if len(response.Series) != 1 || response.Series[0].Name != "series0 (-1h)" {
+		test.Fatalf("\nExpected series `series0 (-1h)'\nbut got  %d series", len(response.Series))
+	} else if response.Series[0].Options["time_shift"] != "-1h" {
+		test.Logf("\nExpected series options to be set\nbut got  %v", response.Series[0].Options)
+		test.Fail()
+	}
+
+	shifted := response.Series[0].Plots
+	if len(shifted) != len(previous.Series[0].Plots) {
+		test.Fatalf("\nExpected %d plots\nbut got  %d", len(previous.Series[0].Plots), len(shifted))
+	}
+
+	for i, p := range previous.Series[0].Plots {
+		if !shifted[i].Time.Equal(p.Time.Add(time.Hour)) || shifted[i].Value != p.Value {
+			test.Logf("\nExpected %v shifted by one hour\nbut got  %v", p, shifted[i])
+			test.Fail()
+			break
+		}
+	}
+
+	expected := []*SeriesError{{Name: "series1", Error: "invalid time shift `foo'"}}
+
+	if !reflect.DeepEqual(expected, response.Errors) {
+		test.Logf("\nExpected %v\nbut got  %v", expected, response.Errors)
+		test.Fail()
+	}
+}
+
+func Test_PlotsSeriesExpr(test *testing.T) {
+	server := newTestPlotServer(test)
+
+	plotReq := &PlotRequest{
+		Sample:    30,
+		startTime: time.Unix(1000020, 0),
+		endTime:   time.Unix(1003620, 0),
+	}
+
+	graph := &library.Graph{
+		Item: library.Item{ID: "graph0", Name: "graph0"},
+		Groups: []*library.OperGroup{
+			{
+				Name: "group0",
+				Type: plot.OperTypeNone,
+				Series: []*library.Series{
+					{Name: "ratio", Expr: "idle1 / total * 100"},
+					{Name: "idle1", Origin: "synthetic", Source: "host1", Metric: "cpu.idle"},
+					{Name: "invalid", Expr: "idle1 + unknown"},
+				},
+			},
+			{
+				Name: "total",
+				Type: plot.OperTypeSum,
+				Series: []*library.Series{
+					{Name: "series0", Origin: "synthetic", Source: "host1", Metric: "cpu.idle"},
+					{Name: "series1", Origin: "synthetic", Source: "host2", Metric: "cpu.idle"},
+				},
+			},
+		},
+	}
+
+	response := executeTestPlotRequest(test, server, plotReq, graph)
+
+	if len(response.Series) != 3 {
+		test.Fatalf("\nExpected %d series\nbut got  %d", 3, len(response.Series))
+	}
+
+	// Expression series come after the other series of their group
+	idle, ratio, total := response.Series[0], response.Series[1], response.Series[2]
+
+	if idle.Name != "idle1" || ratio.Name != "ratio" || total.Name != "total" {
+		test.Fatalf("\nExpected series `idle1', `ratio' and `total'\nbut got  `%s', `%s' and `%s'", idle.Name,
+			ratio.Name, total.Name)
+	}
+
+	for i := range ratio.Plots {
+		expected := idle.Plots[i].Value / total.Plots[i].Value * 100
+		if ratio.Plots[i].Value != expected || !ratio.Plots[i].Time.Equal(idle.Plots[i].Time) {
+			test.Logf("\nExpected %g\nbut got  %g", expected, ratio.Plots[i].Value)
+			test.Fail()
+			break
+		}
+	}
+
+	if ratio.Summary["avg"].IsNaN() {
+		test.Logf("\nExpected expression series to be summarized")
+		test.Fail()
+	}
+
+	expected := []*SeriesError{{Name: "invalid", Error: "unknown `unknown' series"}}
+
+	if !reflect.DeepEqual(expected, response.Errors) {
+		test.Logf("\nExpected %v\nbut got  %v", expected, response.Errors)
+		test.Fail()
+	}
+}
+
+func newTestPlotServer(test *testing.T) *Server {
+	c, err := connector.Connectors["synthetic"]("synthetic", newTestConnectorSettings())
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	server := NewServer("", "", 0)
+	server.Catalog = catalog.NewCatalog()
+
+	records := make(chan *catalog.Record)
+
+	go func() {
+		c.Refresh(context.Background(), "synthetic", records)
+		close(records)
+	}()
+
+	for record := range records {
+		record.OriginalOrigin = record.Origin
+		record.OriginalSource = record.Source
+		record.OriginalMetric = record.Metric
+
+		server.Catalog.Insert(record)
+	}
+
+	return server
+}
+
+func executeTestPlotRequest(test *testing.T, server *Server, plotReq *PlotRequest,
+	graph *library.Graph) *PlotResponse {
+
+	providerQueries, err := server.prepareProviderQueries(plotReq, graph)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	plotSeries, seriesErrors, err := server.executeQueries(context.Background(), providerQueries)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	response, err := makePlotsResponse(plotSeries, seriesErrors, plotReq, graph)
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	return response
+}
+
+type failingTestConnector struct{}
+
+func (c failingTestConnector) GetName() string {
+	return "failing"
+}
+
+func (c failingTestConnector) GetPlots(ctx context.Context, query *plot.Query) ([]*plot.Series, error) {
+	return nil, fmt.Errorf("failing: backend unavailable")
+}
+
+func (c failingTestConnector) Refresh(ctx context.Context, originName string, outputChan chan<- *catalog.Record) error {
+	return nil
+}
+
+func newTestConnectorSettings() map[string]interface{} {
+	return map[string]interface{}{
+		"type":    "synthetic",
+		"seed":    42.0,
+		"sources": []interface{}{"host1", "host2"},
+		"metrics": map[string]interface{}{
+			"cpu.idle":    map[string]interface{}{"type": "sine", "amplitude": 10.0, "offset": 50.0},
+			"net.packets": map[string]interface{}{"type": "counter", "rate": 10.0},
+		},
+	}
+}
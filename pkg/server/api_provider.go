@@ -0,0 +1,257 @@
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/facette/facette/pkg/catalog"
+	"github.com/facette/facette/pkg/logger"
+	"github.com/facette/facette/pkg/provider"
+	"github.com/facette/facette/pkg/utils"
+)
+
+func (server *Server) serveProviders(writer http.ResponseWriter, request *http.Request) {
+	setHTTPCacheHeaders(writer)
+
+	name := routeTrimPrefix(request.URL.Path, urlProvidersPath)
+
+	if name == "" {
+		server.serveProviderList(writer, request)
+		return
+	} else if strings.HasSuffix(name, "/refresh") {
+		server.serveProviderRefresh(writer, request, strings.TrimSuffix(name, "/refresh"))
+		return
+	} else if strings.HasSuffix(name, "/filters/test") {
+		server.serveProviderFiltersTest(writer, request, strings.TrimSuffix(name, "/filters/test"))
+		return
+	}
+
+	if response, status := server.parseShowRequest(writer, request); status != http.StatusOK {
+		server.serveResponse(writer, response, status)
+		return
+	}
+
+	prov, ok := server.getProvider(name)
+	if !ok {
+		server.serveResponse(writer, serverResponse{mesgResourceNotFound}, http.StatusNotFound)
+		return
+	}
+
+	server.serveResponse(writer, server.getProviderStatus(prov), http.StatusOK)
+}
+
+func (server *Server) serveProviderList(writer http.ResponseWriter, request *http.Request) {
+	var offset, limit int
+
+	if response, status := server.parseListRequest(writer, request, &offset, &limit); status != http.StatusOK {
+		server.serveResponse(writer, response, status)
+		return
+	}
+
+	providers := make(ProviderListResponse, 0)
+	for _, prov := range server.getProviders() {
+		if request.FormValue("filter") != "" && !utils.FilterMatch(request.FormValue("filter"), prov.Name) {
+			continue
+		}
+
+		providers = append(providers, server.getProviderStatus(prov))
+	}
+
+	response := &listResponse{
+		list:   providers,
+		offset: offset,
+		limit:  limit,
+	}
+
+	server.applyResponseLimit(writer, request, response)
+
+	server.serveResponse(writer, response.list, http.StatusOK)
+}
+
+func (server *Server) serveProviderRefresh(writer http.ResponseWriter, request *http.Request, name string) {
+	if request.Method != "POST" {
+		server.serveResponse(writer, serverResponse{mesgMethodNotAllowed}, http.StatusMethodNotAllowed)
+		return
+	} else if server.Config.ReadOnly {
+		server.serveResponse(writer, serverResponse{mesgReadOnlyMode}, http.StatusForbidden)
+		return
+	}
+
+	prov, ok := server.getProvider(name)
+	if !ok {
+		server.serveResponse(writer, serverResponse{mesgResourceNotFound}, http.StatusNotFound)
+		return
+	}
+
+	providerWorker := server.getProviderWorker(name)
+	if providerWorker == nil {
+		server.serveResponse(writer, serverResponse{mesgResourceNotFound}, http.StatusNotFound)
+		return
+	}
+
+	seq := prov.NextRefresh()
+
+	providerWorker.SendEvent(eventCatalogRefresh, true, nil)
+
+	// Return immediately unless asked to wait for the refresh completion
+	if wait, _ := strconv.ParseBool(request.FormValue("wait")); !wait {
+		server.serveResponse(writer, nil, http.StatusAccepted)
+		return
+	}
+
+	if err := prov.WaitRefresh(request.Context(), seq); err != nil {
+		// Client went away, nothing left to reply
+		return
+	}
+
+	response := server.getProviderStatus(prov)
+
+	if response.RefreshError != "" {
+		server.serveResponse(writer, response, http.StatusBadGateway)
+		return
+	}
+
+	server.serveResponse(writer, response, http.StatusOK)
+}
+
+func (server *Server) serveProviderFiltersTest(writer http.ResponseWriter, request *http.Request, name string) {
+	var (
+		testReq  ProviderFiltersTestRequest
+		response ProviderFiltersTestResponse
+		err      error
+	)
+
+	if request.Method != "POST" {
+		server.serveResponse(writer, serverResponse{mesgMethodNotAllowed}, http.StatusMethodNotAllowed)
+		return
+	} else if utils.HTTPGetContentType(request) != "application/json" {
+		server.serveResponse(writer, serverResponse{mesgUnsupportedMediaType}, http.StatusUnsupportedMediaType)
+		return
+	}
+
+	prov, ok := server.getProvider(name)
+	if !ok {
+		server.serveResponse(writer, serverResponse{mesgResourceNotFound}, http.StatusNotFound)
+		return
+	}
+
+	body, _ := ioutil.ReadAll(request.Body)
+	if err := json.Unmarshal(body, &testReq); err != nil {
+		logger.Log(logger.LevelError, "server", "%s", err)
+		server.serveResponse(writer, serverResponse{mesgResourceInvalid}, http.StatusBadRequest)
+		return
+	} else if !testReq.Refresh && len(testReq.Records) == 0 {
+		server.serveResponse(writer, serverResponse{mesgMissingParameter}, http.StatusBadRequest)
+		return
+	} else if testReq.Refresh && server.Config.ReadOnly {
+		server.serveResponse(writer, serverResponse{mesgReadOnlyMode}, http.StatusForbidden)
+		return
+	}
+
+	// Trace either live records from the connector or the provided samples
+	if testReq.Refresh {
+		response.Traces, err = prov.TraceRefresh(request.Context())
+	} else {
+		records := make([]*catalog.Record, len(testReq.Records))
+		for i, record := range testReq.Records {
+			records[i] = &catalog.Record{Origin: record.Origin, Source: record.Source, Metric: record.Metric}
+
+			if records[i].Origin == "" {
+				records[i].Origin = prov.Name
+			}
+		}
+
+		response.Traces = prov.TraceFilters(records)
+	}
+
+	if err != nil {
+		response.Error = err.Error()
+		server.serveResponse(writer, response, http.StatusBadGateway)
+		return
+	}
+
+	server.serveResponse(writer, response, http.StatusOK)
+}
+
+func (server *Server) serveHealth(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != "GET" && request.Method != "HEAD" {
+		server.serveResponse(writer, serverResponse{mesgMethodNotAllowed}, http.StatusMethodNotAllowed)
+		return
+	}
+
+	setHTTPCacheHeaders(writer)
+
+	response := healthResponse{
+		Catalog: true,
+		Library: atomic.LoadInt32(&server.libraryLoaded) == 1,
+	}
+
+	// Consider catalog as loaded once all the providers have successfully performed their initial refresh or got their
+	// entries restored from the catalog snapshot, failed refreshes not having populated the catalog
+	for _, prov := range server.getProviders() {
+		prov.RLock()
+		refreshed := !prov.LastRefresh.IsZero() || prov.SnapshotRecords > 0
+		prov.RUnlock()
+
+		if !refreshed {
+			response.Catalog = false
+			break
+		}
+	}
+
+	if !response.Catalog || !response.Library || server.stopping {
+		response.Message = mesgServiceLoading
+		server.serveResponse(writer, response, http.StatusServiceUnavailable)
+		return
+	}
+
+	server.serveResponse(writer, response, http.StatusOK)
+}
+
+func (server *Server) getProviderStatus(prov *provider.Provider) *ProviderResponse {
+	connectorType, _ := prov.Config.Connector["type"].(string)
+
+	response := &ProviderResponse{
+		Name:      prov.Name,
+		Connector: connectorType,
+	}
+
+	prov.RLock()
+
+	if !prov.LastRefresh.IsZero() {
+		response.LastRefresh = prov.LastRefresh.Format(time.RFC3339)
+	}
+
+	response.RefreshDuration = prov.LastRefreshDuration.Seconds()
+	response.RefreshRecords = prov.LastRefreshRecords
+	response.RefreshCount = prov.RefreshCount
+
+	if prov.LastRefreshError != nil {
+		response.RefreshError = prov.LastRefreshError.Error()
+	}
+
+	prov.RUnlock()
+
+	// Report catalog entries contributed by the provider
+	if prov.Connector != nil {
+		stats := server.Catalog.GetConnectorStats(prov.Connector)
+
+		response.Origins = stats.Origins
+		response.Sources = stats.Sources
+		response.Metrics = stats.Metrics
+		response.StaleMetrics = stats.StaleMetrics
+	}
+
+	// Compute plot queries error rate
+	response.Queries, response.QueryErrors = prov.QueryStats()
+	if response.Queries > 0 {
+		response.QueryErrorRate = float64(response.QueryErrors) / float64(response.Queries)
+	}
+
+	return response
+}
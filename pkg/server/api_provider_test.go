@@ -0,0 +1,163 @@
+// +build synthetic
+
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/facette/facette/pkg/config"
+	"github.com/facette/facette/pkg/connector"
+	"github.com/facette/facette/pkg/provider"
+	"github.com/facette/facette/pkg/worker"
+)
+
+func Test_ProviderStatus(test *testing.T) {
+	server := newTestPlotServer(test)
+	prov := newTestProvider(test, server)
+
+	prov.RecordQuery(nil)
+	prov.RecordQuery(nil)
+	prov.RecordQuery(nil)
+	prov.RecordQuery(fmt.Errorf("backend unavailable"))
+
+	expected := &ProviderResponse{
+		Name:           "synthetic",
+		Connector:      "synthetic",
+		Origins:        1,
+		Sources:        2,
+		Metrics:        4,
+		Queries:        4,
+		QueryErrors:    1,
+		QueryErrorRate: 0.25,
+	}
+
+	actual := server.getProviderStatus(prov)
+
+	if *expected != *actual {
+		test.Logf("\nExpected %+v\nbut got  %+v", expected, actual)
+		test.Fail()
+	}
+}
+
+func Test_Health(test *testing.T) {
+	server := newTestPlotServer(test)
+	server.providerWorkers = worker.NewPool()
+
+	prov := newTestProvider(test, server)
+
+	// Providers failing to initialize must not prevent the catalog from being considered loaded
+	discarded := provider.NewProvider("discarded", &config.ProviderConfig{
+		Connector: map[string]interface{}{"type": "synthetic"},
+	}, server.Catalog)
+
+	server.providers[discarded.Name] = discarded
+
+	if err := server.startProviderWorker(discarded); err != nil {
+		test.Fatal(err)
+	} else if _, ok := server.getProvider(discarded.Name); ok {
+		test.Logf("\nExpected `%s' provider to be discarded", discarded.Name)
+		test.Fail()
+	}
+
+	for _, step := range []struct {
+		refresh    bool
+		refreshErr error
+		library    bool
+		status     int
+	}{
+		{false, nil, false, http.StatusServiceUnavailable},
+		{false, nil, true, http.StatusServiceUnavailable},
+		{true, errors.New("connection refused"), true, http.StatusServiceUnavailable},
+		{true, nil, true, http.StatusOK},
+	} {
+		// Only successful refreshes are timestamped by the provider
+		if step.refresh {
+			prov.RefreshCount++
+			prov.LastRefreshError = step.refreshErr
+
+			if step.refreshErr == nil {
+				prov.LastRefresh = time.Now()
+			}
+		}
+
+		if step.library {
+			server.libraryLoaded = 1
+		}
+
+		recorder := httptest.NewRecorder()
+		server.serveHealth(recorder, httptest.NewRequest("GET", urlHealthPath, nil))
+
+		if recorder.Code != step.status {
+			test.Logf("\nExpected %d\nbut got  %d", step.status, recorder.Code)
+			test.Fail()
+		}
+	}
+}
+
+func Test_ProviderFiltersTest(test *testing.T) {
+	server := newTestPlotServer(test)
+	prov := newTestProvider(test, server)
+	defer prov.Close()
+
+	for _, entry := range []struct {
+		body     string
+		readOnly bool
+		status   int
+		traces   int
+	}{
+		{`{"records": [{"source": "host1", "metric": "cpu.idle"}]}`, false, http.StatusOK, 1},
+		{`{"records": [{"source": "host1", "metric": "cpu.idle"}]}`, true, http.StatusOK, 1},
+		{`{"refresh": true}`, false, http.StatusOK, 4},
+		{`{"refresh": true}`, true, http.StatusForbidden, 0},
+		{`{}`, false, http.StatusBadRequest, 0},
+	} {
+		var response ProviderFiltersTestResponse
+
+		server.Config.ReadOnly = entry.readOnly
+
+		request := httptest.NewRequest("POST", urlProvidersPath+"synthetic/filters/test", strings.NewReader(entry.body))
+		request.Header.Set("Content-Type", "application/json")
+
+		recorder := httptest.NewRecorder()
+		server.serveProviders(recorder, request)
+
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+
+		if recorder.Code != entry.status || len(response.Traces) != entry.traces {
+			test.Logf("\nExpected %d status with %d traces for %s\nbut got  %d status with %d traces", entry.status,
+				entry.traces, entry.body, recorder.Code, len(response.Traces))
+			test.Fail()
+		}
+
+		for _, trace := range response.Traces {
+			if !trace.Kept || trace.Input.Origin != "synthetic" {
+				test.Logf("\nExpected record to be kept in `synthetic' origin\nbut got  %+v", trace)
+				test.Fail()
+			}
+		}
+	}
+}
+
+func newTestProvider(test *testing.T, server *Server) *provider.Provider {
+	metric, err := server.Catalog.GetMetric("synthetic", "host1", "cpu.idle")
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	prov := provider.NewProvider("synthetic", &config.ProviderConfig{
+		Connector: newTestConnectorSettings(),
+	}, server.Catalog)
+
+	prov.Connector = metric.GetConnector().(connector.Connector)
+
+	server.providers[prov.Name] = prov
+
+	return prov
+}
@@ -0,0 +1,187 @@
+package server
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/facette/facette/pkg/catalog"
+)
+
+const (
+	catalogSearchGlob   string = "glob"
+	catalogSearchRegexp string = "regexp"
+	catalogSearchFuzzy  string = "fuzzy"
+)
+
+// Matches on metric names rank higher than matches on source names, themselves ranking higher than origin ones
+const (
+	catalogSearchOriginWeight int = 1
+	catalogSearchSourceWeight int = 2
+	catalogSearchMetricWeight int = 3
+)
+
+// catalogMatcher returns the score of a value regarding a search term, 0 meaning that the value doesn't match.
+type catalogMatcher func(value string) int
+
+func newCatalogMatcher(mode, term string) (catalogMatcher, error) {
+	switch mode {
+	case catalogSearchGlob:
+		// Remove slashes from pattern and value as `path.Match' does not handle them
+		pattern := strings.ToLower(strings.Replace(term, "/", "\x1e", -1))
+
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob pattern `%s': %s", term, err)
+		}
+
+		return func(value string) int {
+			value = strings.ToLower(strings.Replace(value, "/", "\x1e", -1))
+
+			if ok, _ := path.Match(pattern, value); !ok {
+				return 0
+			} else if pattern == value {
+				return 100
+			}
+
+			return 50
+		}, nil
+
+	case catalogSearchRegexp:
+		re, err := regexp.Compile(term)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regexp pattern `%s': %s", term, err)
+		}
+
+		return func(value string) int {
+			loc := re.FindStringIndex(value)
+			if loc == nil {
+				return 0
+			} else if value == "" {
+				return 100
+			}
+
+			// Favor matches covering most of the value
+			return 50 + 50*(loc[1]-loc[0])/len(value)
+		}, nil
+
+	case catalogSearchFuzzy:
+		term = strings.ToLower(term)
+
+		return func(value string) int {
+			return fuzzyMatch(term, strings.ToLower(value))
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown search mode `%s'", mode)
+}
+
+// fuzzyMatch checks whether the term characters appear in order in the value, favoring consecutive characters and
+// characters at the beginning of words.
+func fuzzyMatch(term, value string) int {
+	if term == value {
+		return 100
+	}
+
+	score := 0
+	last := -2
+	index := 0
+
+	for i := 0; i < len(term); i++ {
+		offset := strings.IndexByte(value[index:], term[i])
+		if offset == -1 {
+			return 0
+		}
+
+		index += offset
+		score++
+
+		if index == last+1 {
+			score += 5
+		}
+
+		if index == 0 || strings.IndexByte("./-_: ", value[index-1]) != -1 {
+			score += 3
+		}
+
+		last = index
+		index++
+	}
+
+	return score
+}
+
+func searchCatalog(serverCatalog *catalog.Catalog, mode, query, originName,
+	sourceName string) (CatalogSearchListResponse, error) {
+
+	// Each whitespace-separated term must match either the origin, the source or the metric name
+	matchers := []catalogMatcher{}
+
+	for _, term := range strings.Fields(query) {
+		matcher, err := newCatalogMatcher(mode, term)
+		if err != nil {
+			return nil, err
+		}
+
+		matchers = append(matchers, matcher)
+	}
+
+	results := CatalogSearchListResponse{}
+
+	for _, origin := range serverCatalog.GetOrigins() {
+		if originName != "" && origin.Name != originName {
+			continue
+		}
+
+		for _, source := range origin.GetSources() {
+			if sourceName != "" && source.Name != sourceName {
+				continue
+			}
+
+			for _, metric := range source.GetMetrics() {
+				score := 0
+
+				for _, matcher := range matchers {
+					termScore := maxInt(
+						matcher(origin.Name)*catalogSearchOriginWeight,
+						matcher(source.Name)*catalogSearchSourceWeight,
+						matcher(metric.Name)*catalogSearchMetricWeight,
+					)
+
+					if termScore == 0 {
+						score = 0
+						break
+					}
+
+					score += termScore
+				}
+
+				if score == 0 {
+					continue
+				}
+
+				results = append(results, &CatalogSearchResponse{
+					Origin:     origin.Name,
+					Source:     source.Name,
+					Metric:     metric.Name,
+					Attributes: metric.GetAttributes(),
+					Score:      score,
+				})
+			}
+		}
+	}
+
+	return results, nil
+}
+
+func maxInt(values ...int) int {
+	result := values[0]
+
+	for _, value := range values[1:] {
+		if value > result {
+			result = value
+		}
+	}
+
+	return result
+}
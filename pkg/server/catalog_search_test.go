@@ -0,0 +1,111 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/facette/facette/pkg/catalog"
+)
+
+func Test_CatalogSearch(test *testing.T) {
+	serverCatalog := catalog.NewCatalog()
+
+	for _, sourceName := range []string{"web01", "web02", "db01"} {
+		for _, metricName := range []string{"cpu.idle", "cpu.user", "load.shortterm", "network.eth0.rx"} {
+			serverCatalog.Insert(&catalog.Record{Origin: "collectd", Source: sourceName, Metric: metricName})
+		}
+	}
+
+	for _, entry := range []struct {
+		mode     string
+		query    string
+		expected []string
+	}{
+		{catalogSearchGlob, "web01 cpu.*", []string{"collectd/web01/cpu.idle", "collectd/web01/cpu.user"}},
+		{catalogSearchRegexp, "^db load", []string{"collectd/db01/load.shortterm"}},
+		{catalogSearchFuzzy, "db rx", []string{"collectd/db01/network.eth0.rx"}},
+		{catalogSearchFuzzy, "w2 cidl", []string{"collectd/web02/cpu.idle"}},
+		{catalogSearchFuzzy, "nothing", []string{}},
+	} {
+		results, err := searchCatalog(serverCatalog, entry.mode, entry.query, "", "")
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		sort.Sort(results)
+
+		actual := []string{}
+		for _, result := range results {
+			actual = append(actual, result.Origin+"/"+result.Source+"/"+result.Metric)
+		}
+
+		if !reflect.DeepEqual(entry.expected, actual) {
+			test.Logf("\nExpected %v for %s `%s' query\nbut got  %v", entry.expected, entry.mode, entry.query, actual)
+			test.Fail()
+		}
+	}
+
+	if _, err := searchCatalog(serverCatalog, catalogSearchRegexp, "cpu(", "", ""); err == nil {
+		test.Logf("\nExpected invalid regexp pattern to fail")
+		test.Fail()
+	}
+}
+
+func Test_CatalogSearchRanking(test *testing.T) {
+	serverCatalog := catalog.NewCatalog()
+
+	for _, metricName := range []string{"cpu", "collectd.cpu.user", "cpu.idle"} {
+		serverCatalog.Insert(&catalog.Record{Origin: "collectd", Source: "host1", Metric: metricName})
+	}
+
+	results, err := searchCatalog(serverCatalog, catalogSearchFuzzy, "cpu", "", "")
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	sort.Sort(results)
+
+	expected := []string{"cpu", "cpu.idle", "collectd.cpu.user"}
+
+	actual := []string{}
+	for _, result := range results {
+		actual = append(actual, result.Metric)
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		test.Logf("\nExpected %v\nbut got  %v", expected, actual)
+		test.Fail()
+	}
+}
+
+func Test_CatalogSearchRequest(test *testing.T) {
+	server := NewServer("", "", 0)
+	server.Catalog = catalog.NewCatalog()
+
+	for _, sourceName := range []string{"host1", "host2", "host3"} {
+		server.Catalog.Insert(&catalog.Record{Origin: "collectd", Source: sourceName, Metric: "cpu.idle"})
+	}
+
+	for _, entry := range []struct {
+		url    string
+		status int
+		total  string
+	}{
+		{urlCatalogPath + "search?q=host&limit=2", http.StatusOK, "3"},
+		{urlCatalogPath + "search?q=host&source=host2", http.StatusOK, "1"},
+		{urlCatalogPath + "search?q=host&mode=unknown", http.StatusBadRequest, ""},
+		{urlCatalogPath + "search", http.StatusBadRequest, ""},
+	} {
+		recorder := httptest.NewRecorder()
+		server.serveCatalog(recorder, httptest.NewRequest("GET", entry.url, nil))
+
+		if recorder.Code != entry.status || recorder.Header().Get("X-Total-Records") != entry.total {
+			test.Logf("\nExpected %d status with %q records for `%s'\nbut got  %d status with %q records",
+				entry.status, entry.total, entry.url, recorder.Code, recorder.Header().Get("X-Total-Records"))
+			test.Fail()
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	"github.com/facette/facette/pkg/catalog"
+	"github.com/facette/facette/pkg/connector"
+	"github.com/facette/facette/pkg/logger"
+)
+
+const (
+	catalogSnapshotFile    string = "catalog.json.gz"
+	catalogSnapshotVersion int    = 1
+)
+
+type catalogSnapshot struct {
+	Version   int                                          `json:"version"`
+	Modified  time.Time                                    `json:"modified"`
+	Providers map[string]map[string]*catalogSnapshotOrigin `json:"providers"`
+}
+
+type catalogSnapshotOrigin struct {
+	OriginalName string                            `json:"original_name"`
+	Sources      map[string]*catalogSnapshotSource `json:"sources"`
+}
+
+type catalogSnapshotSource struct {
+	OriginalName string            `json:"original_name"`
+	Metrics      map[string]string `json:"metrics"`
+}
+
+func (server *Server) saveCatalogSnapshot() error {
+	snapshot := catalogSnapshot{
+		Version:   catalogSnapshotVersion,
+		Modified:  time.Now(),
+		Providers: make(map[string]map[string]*catalogSnapshotOrigin),
+	}
+
+	for _, origin := range server.Catalog.GetOrigins() {
+		for _, source := range origin.GetSources() {
+			for _, metric := range source.GetMetrics() {
+				conn, ok := metric.GetConnector().(connector.Connector)
+				if !ok {
+					continue
+				}
+
+				providerName := conn.GetName()
+
+				if _, ok := snapshot.Providers[providerName]; !ok {
+					snapshot.Providers[providerName] = make(map[string]*catalogSnapshotOrigin)
+				}
+
+				if _, ok := snapshot.Providers[providerName][origin.Name]; !ok {
+					snapshot.Providers[providerName][origin.Name] = &catalogSnapshotOrigin{
+						OriginalName: origin.OriginalName,
+						Sources:      make(map[string]*catalogSnapshotSource),
+					}
+				}
+
+				snapshotOrigin := snapshot.Providers[providerName][origin.Name]
+
+				if _, ok := snapshotOrigin.Sources[source.Name]; !ok {
+					snapshotOrigin.Sources[source.Name] = &catalogSnapshotSource{
+						OriginalName: source.OriginalName,
+						Metrics:      make(map[string]string),
+					}
+				}
+
+				snapshotOrigin.Sources[source.Name].Metrics[metric.Name] = metric.OriginalName
+			}
+		}
+	}
+
+	// Write snapshot to a temporary file first, then move it so that an interrupted write can't corrupt it
+	filePath := path.Join(server.Config.DataDir, catalogSnapshotFile)
+
+	if err := os.MkdirAll(server.Config.DataDir, 0755); err != nil {
+		return err
+	}
+
+	fd, err := ioutil.TempFile(server.Config.DataDir, catalogSnapshotFile)
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(fd.Name())
+
+	writer := gzip.NewWriter(fd)
+
+	if err := json.NewEncoder(writer).Encode(snapshot); err != nil {
+		fd.Close()
+		return err
+	} else if err := writer.Close(); err != nil {
+		fd.Close()
+		return err
+	} else if err := fd.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(fd.Name(), filePath)
+}
+
+func (server *Server) loadCatalogSnapshot() error {
+	var snapshot catalogSnapshot
+
+	fd, err := os.Open(path.Join(server.Config.DataDir, catalogSnapshotFile))
+	if err != nil {
+		return err
+	}
+
+	defer fd.Close()
+
+	reader, err := gzip.NewReader(fd)
+	if err != nil {
+		return err
+	}
+
+	defer reader.Close()
+
+	if err := json.NewDecoder(reader).Decode(&snapshot); err != nil {
+		return err
+	} else if snapshot.Version != catalogSnapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", snapshot.Version)
+	}
+
+	for providerName, origins := range snapshot.Providers {
+		// Skip entries from providers that are no longer defined or failed to initialize
+		prov, ok := server.getProvider(providerName)
+		if !ok || prov.Connector == nil {
+			continue
+		}
+
+		count := 0
+
+		for originName, origin := range origins {
+			for sourceName, source := range origin.Sources {
+				for metricName, metricOriginalName := range source.Metrics {
+					server.Catalog.Insert(&catalog.Record{
+						Origin:         originName,
+						Source:         sourceName,
+						Metric:         metricName,
+						OriginalOrigin: origin.OriginalName,
+						OriginalSource: source.OriginalName,
+						OriginalMetric: metricOriginalName,
+						Connector:      prov.Connector,
+						Stale:          true,
+					})
+
+					count++
+				}
+			}
+		}
+
+		prov.Lock()
+		prov.SnapshotRecords = count
+		prov.Unlock()
+
+		logger.Log(logger.LevelDebug, "server", "restored %d metrics from snapshot for provider `%s'", count,
+			providerName)
+	}
+
+	return nil
+}
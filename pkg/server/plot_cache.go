@@ -0,0 +1,306 @@
+package server
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/facette/facette/pkg/config"
+	"github.com/facette/facette/pkg/plot"
+)
+
+const (
+	// Estimated memory footprint of a cached plot (time.Time and float64 value) and of an entry without its plots
+	plotCacheItemSize  int64 = 32
+	plotCacheEntrySize int64 = 256
+)
+
+type plotCacheKey struct {
+	provider string
+	origin   string
+	source   string
+	metric   string
+	start    int64
+	end      int64
+	sample   int
+}
+
+type plotCacheEntry struct {
+	key    plotCacheKey
+	series plot.Series
+	size   int64
+	expire time.Time
+}
+
+type plotCacheCall struct {
+	done      chan struct{}
+	series    *plot.Series
+	err       error
+	cancelled bool
+}
+
+type plotCacheStats struct {
+	Entries   int    `json:"entries"`
+	Size      int64  `json:"size"`
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Coalesced uint64 `json:"coalesced"`
+	Evictions uint64 `json:"evictions"`
+}
+
+type plotCache struct {
+	ttl        time.Duration
+	historyTTL time.Duration
+	maxSize    int64
+	size       int64
+	entries    map[plotCacheKey]*list.Element
+	lru        *list.List
+	calls      map[plotCacheKey]*plotCacheCall
+	hits       uint64
+	misses     uint64
+	coalesced  uint64
+	evictions  uint64
+	sync.Mutex
+}
+
+func newPlotCache(ttl, historyTTL time.Duration, maxSize int64) *plotCache {
+	return &plotCache{
+		ttl:        ttl,
+		historyTTL: historyTTL,
+		maxSize:    maxSize,
+		entries:    make(map[plotCacheKey]*list.Element),
+		lru:        list.New(),
+		calls:      make(map[plotCacheKey]*plotCacheCall),
+	}
+}
+
+// getPlots returns the plots matching a provider query, only requesting the backend for series neither cached nor
+// already being fetched by a concurrent request.
+func (c *plotCache) getPlots(ctx context.Context, providerName string, query plot.Query,
+	fetch func(context.Context, *plot.Query) ([]*plot.Series, error)) ([]*plot.Series, error) {
+
+	sample := query.Sample
+	if sample <= 0 {
+		sample = config.DefaultPlotSample
+	}
+
+	// Align time window on the query step so that close requests share the same cache entries
+	step := int64(query.EndTime.Sub(query.StartTime).Seconds()) / int64(sample)
+	if step < 1 {
+		step = 1
+	}
+
+	start := query.StartTime.Unix() - query.StartTime.Unix()%step
+	end := query.EndTime.Unix()
+	if end%step != 0 {
+		end += step - end%step
+	}
+
+	query.StartTime = time.Unix(start, 0)
+	query.EndTime = time.Unix(end, 0)
+
+	// Use a longer lifetime for time windows fully in the past, as their data is not expected to change anymore
+	ttl := c.ttl
+	if c.historyTTL > ttl && end+step < time.Now().Unix() {
+		ttl = c.historyTTL
+	}
+
+	keys := make([]plotCacheKey, len(query.Series))
+	for i, series := range query.Series {
+		keys[i] = plotCacheKey{
+			provider: providerName,
+			origin:   series.Origin,
+			source:   series.Source,
+			metric:   series.Metric,
+			start:    start,
+			end:      end,
+			sample:   query.Sample,
+		}
+	}
+
+	results := make([]*plot.Series, len(keys))
+
+	for {
+		var owned []int
+
+		waiting := make(map[int]*plotCacheCall)
+
+		c.Lock()
+
+		for i, key := range keys {
+			if results[i] != nil {
+				continue
+			}
+
+			if series := c.get(key); series != nil {
+				results[i] = series
+				c.hits++
+			} else if call, ok := c.calls[key]; ok {
+				waiting[i] = call
+				c.coalesced++
+			} else {
+				c.calls[key] = &plotCacheCall{done: make(chan struct{})}
+				owned = append(owned, i)
+				c.misses++
+			}
+		}
+
+		c.Unlock()
+
+		// Fetch owned series from the backend and release concurrent requests waiting for them
+		if len(owned) > 0 {
+			ownedQuery := query
+			ownedQuery.Series = make([]plot.QuerySeries, len(owned))
+
+			for j, i := range owned {
+				ownedQuery.Series[j] = query.Series[i]
+			}
+
+			plots, err := fetch(ctx, &ownedQuery)
+			if err == nil && len(plots) != len(owned) {
+				err = fmt.Errorf("unexpected number of series returned: %d instead of %d", len(plots), len(owned))
+			}
+
+			c.Lock()
+
+			for j, i := range owned {
+				call := c.calls[keys[i]]
+				delete(c.calls, keys[i])
+
+				if err != nil {
+					call.err = err
+					call.cancelled = ctx.Err() != nil
+				} else {
+					call.series = plots[j]
+					c.set(keys[i], plots[j], ttl)
+					results[i] = plotCacheCopySeries(plots[j])
+				}
+
+				close(call.done)
+			}
+
+			c.Unlock()
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		retry := false
+
+		for i, call := range waiting {
+			select {
+			case <-call.done:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+
+			if call.err != nil {
+				// Retry if the request owning the call got cancelled while the current one is still active
+				if call.cancelled {
+					retry = true
+					continue
+				}
+
+				return nil, call.err
+			}
+
+			results[i] = plotCacheCopySeries(call.series)
+		}
+
+		if !retry {
+			break
+		}
+	}
+
+	return results, nil
+}
+
+func (c *plotCache) purge(providerName string) {
+	c.Lock()
+	defer c.Unlock()
+
+	for key, element := range c.entries {
+		if key.provider == providerName {
+			c.remove(element)
+		}
+	}
+}
+
+func (c *plotCache) stats() *plotCacheStats {
+	c.Lock()
+	defer c.Unlock()
+
+	return &plotCacheStats{
+		Entries:   len(c.entries),
+		Size:      c.size,
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Coalesced: c.coalesced,
+		Evictions: c.evictions,
+	}
+}
+
+func (c *plotCache) get(key plotCacheKey) *plot.Series {
+	element, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+
+	entry := element.Value.(*plotCacheEntry)
+	if time.Now().After(entry.expire) {
+		c.remove(element)
+		return nil
+	}
+
+	c.lru.MoveToFront(element)
+
+	return plotCacheCopySeries(&entry.series)
+}
+
+func (c *plotCache) set(key plotCacheKey, series *plot.Series, ttl time.Duration) {
+	entry := &plotCacheEntry{
+		key:    key,
+		series: *plotCacheCopySeries(series),
+		size:   plotCacheEntrySize + int64(len(series.Plots))*plotCacheItemSize,
+		expire: time.Now().Add(ttl),
+	}
+
+	if entry.size > c.maxSize {
+		return
+	}
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+
+	c.entries[key] = c.lru.PushFront(entry)
+	c.size += entry.size
+
+	// Evict least recently used entries until memory bound is satisfied
+	for c.size > c.maxSize {
+		c.remove(c.lru.Back())
+		c.evictions++
+	}
+}
+
+func (c *plotCache) remove(element *list.Element) {
+	entry := c.lru.Remove(element).(*plotCacheEntry)
+	delete(c.entries, entry.key)
+	c.size -= entry.size
+}
+
+func plotCacheCopySeries(series *plot.Series) *plot.Series {
+	// Copy plots as they get altered in place while building plot responses
+	result := &plot.Series{
+		Name:  series.Name,
+		Plots: make([]plot.Plot, len(series.Plots)),
+		Step:  series.Step,
+	}
+
+	copy(result.Plots, series.Plots)
+
+	return result
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/facette/facette/pkg/plot"
+)
+
+func Test_PlotCacheHit(test *testing.T) {
+	var count int32
+
+	cache := newPlotCache(time.Minute, time.Hour, 1024*1024)
+
+	fetch := func(ctx context.Context, query *plot.Query) ([]*plot.Series, error) {
+		atomic.AddInt32(&count, 1)
+		return plotCacheTestSeries(query), nil
+	}
+
+	query := plotCacheTestQuery(time.Unix(3605, 0), time.Unix(7205, 0), "host1")
+
+	for i := 0; i < 3; i++ {
+		series, err := cache.getPlots(context.Background(), "provider", query, fetch)
+		if err != nil {
+			test.Fatal(err)
+		}
+
+		// Alter returned plots, which must not affect cached ones
+		series[0].Scale(2)
+
+		if series[0].Plots[0].Value != 2 {
+			test.Logf("\nExpected %g\nbut got  %g", 2.0, series[0].Plots[0].Value)
+			test.Fail()
+		}
+
+		// Shift time window by less than a step, which must still match the same cache entry
+		query.StartTime = query.StartTime.Add(time.Second)
+		query.EndTime = query.EndTime.Add(time.Second)
+	}
+
+	if count != 1 {
+		test.Logf("\nExpected %d backend call\nbut got  %d", 1, count)
+		test.Fail()
+	}
+
+	if stats := cache.stats(); stats.Hits != 2 || stats.Misses != 1 || stats.Entries != 1 {
+		test.Logf("\nExpected 2 hits, 1 miss and 1 entry\nbut got  %d hits, %d misses and %d entries", stats.Hits,
+			stats.Misses, stats.Entries)
+		test.Fail()
+	}
+}
+
+func Test_PlotCacheCoalesce(test *testing.T) {
+	var (
+		count int32
+		wg    sync.WaitGroup
+	)
+
+	cache := newPlotCache(time.Minute, time.Hour, 1024*1024)
+
+	release := make(chan struct{})
+
+	fetch := func(ctx context.Context, query *plot.Query) ([]*plot.Series, error) {
+		atomic.AddInt32(&count, 1)
+		<-release
+		return plotCacheTestSeries(query), nil
+	}
+
+	query := plotCacheTestQuery(time.Unix(3600, 0), time.Unix(7200, 0), "host1")
+
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if _, err := cache.getPlots(context.Background(), "provider", query, fetch); err != nil {
+				test.Log(err)
+				test.Fail()
+			}
+		}()
+	}
+
+	// Wait for all requests to be either fetching or waiting before releasing the backend call
+	for {
+		stats := cache.stats()
+		if stats.Misses+stats.Coalesced == 5 {
+			break
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	close(release)
+	wg.Wait()
+
+	if count != 1 {
+		test.Logf("\nExpected %d backend call\nbut got  %d", 1, count)
+		test.Fail()
+	}
+}
+
+func Test_PlotCacheEviction(test *testing.T) {
+	fetch := func(ctx context.Context, query *plot.Query) ([]*plot.Series, error) {
+		return plotCacheTestSeries(query), nil
+	}
+
+	// Only allow two entries to fit in the cache
+	cache := newPlotCache(time.Minute, time.Hour, 2*(plotCacheEntrySize+10*plotCacheItemSize))
+
+	for _, sourceName := range []string{"host1", "host2", "host1", "host3"} {
+		query := plotCacheTestQuery(time.Unix(3600, 0), time.Unix(7200, 0), sourceName)
+
+		if _, err := cache.getPlots(context.Background(), "provider", query, fetch); err != nil {
+			test.Fatal(err)
+		}
+	}
+
+	// host2 being the least recently used entry, it must have been evicted
+	cache.Lock()
+	_, ok := cache.entries[plotCacheKey{
+		provider: "provider",
+		origin:   "origin",
+		source:   "host2",
+		metric:   "metric",
+		start:    3600,
+		end:      7200,
+		sample:   10,
+	}]
+	cache.Unlock()
+
+	if stats := cache.stats(); ok || stats.Evictions != 1 || stats.Entries != 2 {
+		test.Logf("\nExpected 1 eviction and 2 entries\nbut got  %d evictions and %d entries", stats.Evictions,
+			stats.Entries)
+		test.Fail()
+	}
+}
+
+func plotCacheTestQuery(startTime, endTime time.Time, sourceName string) plot.Query {
+	return plot.Query{
+		StartTime: startTime,
+		EndTime:   endTime,
+		Sample:    10,
+		Series: []plot.QuerySeries{
+			{Name: "series0", Origin: "origin", Source: sourceName, Metric: "metric"},
+		},
+	}
+}
+
+func plotCacheTestSeries(query *plot.Query) []*plot.Series {
+	result := make([]*plot.Series, len(query.Series))
+
+	for i, s := range query.Series {
+		result[i] = &plot.Series{Name: s.Name, Step: 360}
+
+		for j := 0; j < 10; j++ {
+			result[i].Plots = append(result[i].Plots, plot.Plot{
+				Time:  query.StartTime.Add(time.Duration(j*360) * time.Second),
+				Value: 1,
+			})
+		}
+	}
+
+	return result
+}
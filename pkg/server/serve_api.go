@@ -66,7 +66,7 @@ func (server *Server) getStats(writer http.ResponseWriter, request *http.Request
 		}
 	}
 
-	return &statsResponse{
+	stats := &statsResponse{
 		Origins:      len(server.Catalog.GetOrigins()),
 		Sources:      sourceSet.Size(),
 		Metrics:      metricSet.Size(),
@@ -75,4 +75,10 @@ func (server *Server) getStats(writer http.ResponseWriter, request *http.Request
 		SourceGroups: sourceGroupsCount,
 		MetricGroups: metricGroupsCount,
 	}
+
+	if server.plotCache != nil {
+		stats.PlotCache = server.plotCache.stats()
+	}
+
+	return stats
 }
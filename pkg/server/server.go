@@ -9,6 +9,7 @@ import (
 	"sort"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/facette/facette/pkg/catalog"
@@ -33,7 +34,9 @@ type Server struct {
 	Catalog         *catalog.Catalog
 	Library         *library.Library
 	providers       map[string]*provider.Provider
+	providersLock   sync.RWMutex
 	providerWorkers worker.Pool
+	plotCache       *plotCache
 	catalogWorker   *worker.Worker
 	serveWorker     *worker.Worker
 	configPath      string
@@ -41,6 +44,7 @@ type Server struct {
 	logLevel        int
 	startTime       time.Time
 	stopping        bool
+	libraryLoaded   int32
 	wg              *sync.WaitGroup
 	buildInfo       *buildInfo
 }
@@ -49,19 +53,27 @@ type Server struct {
 func NewServer(configPath, logPath string, logLevel int) *Server {
 	return &Server{
 		Config: &config.Config{
-			BindAddr:     config.DefaultBindAddr,
-			BaseDir:      config.DefaultBaseDir,
-			DataDir:      config.DefaultDataDir,
-			ProvidersDir: config.DefaultProvidersDir,
-			PidFile:      config.DefaultPidFile,
-			SocketUser:   config.DefaultSocketUser,
-			SocketGroup:  config.DefaultSocketGroup,
+			BindAddr:                config.DefaultBindAddr,
+			BaseDir:                 config.DefaultBaseDir,
+			DataDir:                 config.DefaultDataDir,
+			ProvidersDir:            config.DefaultProvidersDir,
+			PidFile:                 config.DefaultPidFile,
+			SocketUser:              config.DefaultSocketUser,
+			SocketGroup:             config.DefaultSocketGroup,
+			QueryWorkers:            config.DefaultQueryWorkers,
+			CatalogSnapshotInterval: config.DefaultCatalogSnapshotInterval,
+			PlotCache: &config.PlotCacheConfig{
+				TTL:        config.DefaultPlotCacheTTL,
+				HistoryTTL: config.DefaultPlotCacheHistoryTTL,
+				MaxSize:    config.DefaultPlotCacheMaxSize,
+			},
 		},
-		configPath: configPath,
-		logPath:    logPath,
-		logLevel:   logLevel,
-		providers:  make(map[string]*provider.Provider),
-		wg:         &sync.WaitGroup{},
+		configPath:      configPath,
+		logPath:         logPath,
+		logLevel:        logLevel,
+		providers:       make(map[string]*provider.Provider),
+		providerWorkers: worker.NewPool(),
+		wg:              &sync.WaitGroup{},
 	}
 }
 
@@ -71,6 +83,70 @@ func (server *Server) Refresh() {
 	server.Library.Refresh()
 }
 
+// Reload reloads the providers definitions, starting, restarting or stopping their workers accordingly.
+func (server *Server) Reload() error {
+	logger.Log(logger.LevelInfo, "server", "reloading providers definitions")
+
+	providers, err := server.Config.LoadProviders()
+	if err != nil {
+		logger.Log(logger.LevelError, "server", "unable to reload providers: %s", err)
+		return err
+	}
+
+	// Stop providers being either removed or changed
+	for _, prov := range server.getProviders() {
+		if providerConfig, ok := providers[prov.Name]; ok && providerConfig.Equal(prov.Config) {
+			providers[prov.Name] = prov.Config
+			continue
+		}
+
+		logger.Log(logger.LevelInfo, "server", "stopping provider `%s'", prov.Name)
+
+		server.providersLock.Lock()
+		delete(server.providers, prov.Name)
+		server.providersLock.Unlock()
+
+		server.stopProviderWorker(prov)
+	}
+
+	// Start new and changed providers
+	for providerName, providerConfig := range providers {
+		if _, ok := server.getProvider(providerName); ok {
+			continue
+		}
+
+		logger.Log(logger.LevelInfo, "server", "starting provider `%s'", providerName)
+
+		prov := provider.NewProvider(providerName, providerConfig, server.Catalog)
+
+		server.providersLock.Lock()
+		server.providers[providerName] = prov
+		server.providersLock.Unlock()
+
+		if err := server.startProviderWorker(prov); err != nil {
+			logger.Log(logger.LevelError, "server", "%s", err)
+
+			server.providersLock.Lock()
+			delete(server.providers, providerName)
+			server.providersLock.Unlock()
+
+			// Keep failed provider definition so that it gets retried upon next reload
+			prov.Close()
+			continue
+		}
+
+		if providerWorker := server.getProviderWorker(providerName); providerWorker != nil {
+			providerWorker.SendEvent(eventCatalogRefresh, true, nil)
+		}
+	}
+
+	server.Config.Lock()
+	server.Config.Providers = providers
+	server.Config.Unlock()
+
+	return nil
+}
+
 // Run starts the server serving the HTTP responses.
 func (server *Server) Run() error {
 	server.startTime = time.Now()
@@ -138,13 +214,22 @@ func (server *Server) Run() error {
 	// Create new catalog instance
 	server.Catalog = catalog.NewCatalog()
 
+	// Create plot cache instance if enabled
+	if server.Config.PlotCache != nil && server.Config.PlotCache.TTL > 0 {
+		server.plotCache = newPlotCache(
+			time.Duration(server.Config.PlotCache.TTL)*time.Second,
+			time.Duration(server.Config.PlotCache.HistoryTTL)*time.Second,
+			int64(server.Config.PlotCache.MaxSize)*1024*1024,
+		)
+	}
+
 	// Instanciate catalog worker
 	server.catalogWorker = worker.NewWorker()
 	server.catalogWorker.RegisterEvent(eventInit, workerCatalogInit)
 	server.catalogWorker.RegisterEvent(eventShutdown, workerCatalogShutdown)
 	server.catalogWorker.RegisterEvent(eventRun, workerCatalogRun)
 
-	if err := server.catalogWorker.SendEvent(eventInit, false, server.Catalog); err != nil {
+	if err := server.catalogWorker.SendEvent(eventInit, false, server); err != nil {
 		return err
 	}
 
@@ -159,12 +244,23 @@ func (server *Server) Run() error {
 		return err
 	}
 
+	// Restore catalog from last snapshot while providers perform their initial refresh
+	if server.Config.CatalogSnapshotInterval > 0 {
+		if err := server.loadCatalogSnapshot(); err != nil && !os.IsNotExist(err) {
+			logger.Log(logger.LevelWarning, "server", "unable to load catalog snapshot: %s", err)
+		}
+	}
+
 	// Send initial catalog refresh event to provider workers
 	server.providerWorkers.Broadcast(eventCatalogRefresh, nil)
 
 	// Create library instance
 	server.Library = library.NewLibrary(server.Config, server.Catalog)
-	go server.Library.Refresh()
+
+	go func() {
+		server.Library.Refresh()
+		atomic.StoreInt32(&server.libraryLoaded, 1)
+	}()
 
 	// Instanciate serve worker
 	server.serveWorker = worker.NewWorker()
@@ -0,0 +1,240 @@
+// +build synthetic
+
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/facette/facette/pkg/catalog"
+	"github.com/facette/facette/pkg/connector"
+	"github.com/facette/facette/pkg/provider"
+	"github.com/facette/facette/pkg/worker"
+)
+
+func Test_ServerReload(test *testing.T) {
+	dirPath, err := ioutil.TempDir("", "facette")
+	if err != nil {
+		test.Fatal(err)
+	}
+	defer os.RemoveAll(dirPath)
+
+	server := NewServer("", "", 0)
+	server.Config.ProvidersDir = dirPath
+	server.Catalog = catalog.NewCatalog()
+	server.providerWorkers = worker.NewPool()
+
+	go func() {
+		for record := range server.Catalog.RecordChan {
+			server.Catalog.Insert(record)
+		}
+	}()
+
+	writeTestProvider(test, dirPath, "provider1", "host1")
+	writeTestProvider(test, dirPath, "provider2", "host1")
+
+	reloadTestServer(test, server, []string{"provider1", "provider2"})
+
+	// Change first provider, remove second one and add a new one
+	writeTestProvider(test, dirPath, "provider1", "host2")
+	os.Remove(path.Join(dirPath, "provider2.json"))
+	writeTestProvider(test, dirPath, "provider3", "host1")
+
+	reloadTestServer(test, server, []string{"provider1", "provider3"})
+
+	if server.Catalog.OriginExists("provider2") {
+		test.Logf("\nExpected `provider2' origin to be purged")
+		test.Fail()
+	}
+
+	if _, err := server.Catalog.GetSource("provider1", "host1"); err == nil {
+		test.Logf("\nExpected `host1' source to be purged from `provider1' origin")
+		test.Fail()
+	}
+
+	server.stopProviderWorkers()
+}
+
+func Test_ServerReloadRetry(test *testing.T) {
+	dirPath, err := ioutil.TempDir("", "facette")
+	if err != nil {
+		test.Fatal(err)
+	}
+	defer os.RemoveAll(dirPath)
+
+	server := NewServer("", "", 0)
+	server.Config.ProvidersDir = dirPath
+	server.Catalog = catalog.NewCatalog()
+
+	go func() {
+		for record := range server.Catalog.RecordChan {
+			server.Catalog.Insert(record)
+		}
+	}()
+
+	// Declare a provider whose connector type is not available yet
+	if err := ioutil.WriteFile(path.Join(dirPath, "provider1.json"), []byte(`{"connector": {"type": "retry", `+
+		`"sources": ["host1"], "metrics": {"cpu.idle": {"type": "sine"}}}}`), 0644); err != nil {
+		test.Fatal(err)
+	}
+
+	reloadTestServer(test, server, []string{})
+
+	if _, ok := server.Config.Providers["provider1"]; !ok {
+		test.Logf("\nExpected failed `provider1' provider definition to be kept")
+		test.Fail()
+	}
+
+	// Make connector type available, then check failed provider is started upon next reload
+	connector.Connectors["retry"] = connector.Connectors["synthetic"]
+	defer delete(connector.Connectors, "retry")
+
+	reloadTestServer(test, server, []string{"provider1"})
+
+	server.stopProviderWorkers()
+}
+
+func writeTestProvider(test *testing.T, dirPath, name, sourceName string) {
+	if err := ioutil.WriteFile(path.Join(dirPath, name+".json"), []byte(`{"connector": {"type": "synthetic", `+
+		`"sources": ["`+sourceName+`"], "metrics": {"cpu.idle": {"type": "sine"}}}}`), 0644); err != nil {
+		test.Fatal(err)
+	}
+}
+
+func reloadTestServer(test *testing.T, server *Server, expected []string) {
+	if err := server.Reload(); err != nil {
+		test.Fatal(err)
+	}
+
+	for _, name := range expected {
+		prov, ok := server.getProvider(name)
+		if !ok {
+			test.Fatalf("\nExpected `%s' provider to be running", name)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := prov.WaitRefresh(ctx, 1); err != nil {
+			test.Fatal(err)
+		}
+	}
+
+	if len(server.getProviders()) != len(expected) {
+		test.Fatalf("\nExpected %d providers\nbut got  %d", len(expected), len(server.getProviders()))
+	}
+
+	// Wait for catalog to receive the records
+	for i := 0; i < 100; i++ {
+		found := 0
+
+		for _, name := range expected {
+			if server.Catalog.OriginExists(name) {
+				found++
+			}
+		}
+
+		if found == len(expected) {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	test.Fatalf("\nExpected origins %v to be present in catalog", expected)
+}
+
+func Test_CatalogSnapshot(test *testing.T) {
+	dirPath, err := ioutil.TempDir("", "facette")
+	if err != nil {
+		test.Fatal(err)
+	}
+	defer os.RemoveAll(dirPath)
+
+	server := newTestSnapshotServer(dirPath)
+
+	writeTestProvider(test, dirPath, "provider1", "host1")
+	reloadTestServer(test, server, []string{"provider1"})
+
+	if err := server.saveCatalogSnapshot(); err != nil {
+		test.Fatal(err)
+	}
+
+	server.stopProviderWorkers()
+
+	// Restore snapshot in a new server instance, with a provider no longer emitting the `host1' source
+	writeTestProvider(test, dirPath, "provider1", "host2")
+
+	server = newTestSnapshotServer(dirPath)
+
+	providers, err := server.Config.LoadProviders()
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	prov := provider.NewProvider("provider1", providers["provider1"], server.Catalog)
+	server.providers["provider1"] = prov
+
+	if err := server.startProviderWorkers(); err != nil {
+		test.Fatal(err)
+	}
+	defer server.stopProviderWorkers()
+
+	if err := server.loadCatalogSnapshot(); err != nil {
+		test.Fatal(err)
+	}
+
+	metric, err := server.Catalog.GetMetric("provider1", "host1", "cpu.idle")
+	if err != nil {
+		test.Fatal(err)
+	} else if !metric.IsStale() {
+		test.Logf("\nExpected restored metric to be stale")
+		test.Fail()
+	}
+
+	if prov.SnapshotRecords != 1 {
+		test.Logf("\nExpected %d snapshot records\nbut got  %d", 1, prov.SnapshotRecords)
+		test.Fail()
+	}
+
+	// Refresh provider and check for stale entries reconciliation
+	if err := prov.Refresh(context.Background()); err != nil {
+		test.Fatal(err)
+	}
+
+	if _, err := server.Catalog.GetSource("provider1", "host1"); err == nil {
+		test.Logf("\nExpected stale `host1' source to be purged")
+		test.Fail()
+	}
+
+	for i := 0; i < 100; i++ {
+		if _, err := server.Catalog.GetSource("provider1", "host2"); err == nil {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	test.Logf("\nExpected `host2' source to be present in catalog")
+	test.Fail()
+}
+
+func newTestSnapshotServer(dirPath string) *Server {
+	server := NewServer("", "", 0)
+	server.Config.DataDir = path.Join(dirPath, "data")
+	server.Config.ProvidersDir = dirPath
+	server.Catalog = catalog.NewCatalog()
+	server.providerWorkers = worker.NewPool()
+
+	go func() {
+		for record := range server.Catalog.RecordChan {
+			server.Catalog.Insert(record)
+		}
+	}()
+
+	return server
+}
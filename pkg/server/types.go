@@ -6,6 +6,7 @@ import (
 	"github.com/facette/facette/pkg/connector"
 	"github.com/facette/facette/pkg/library"
 	"github.com/facette/facette/pkg/plot"
+	"github.com/facette/facette/pkg/provider"
 	"github.com/facette/natsort"
 )
 
@@ -57,6 +58,42 @@ type MetricResponse struct {
 	Sources []string `json:"sources"`
 }
 
+// CatalogSearchResponse represents a catalog search result response structure in the server backend.
+type CatalogSearchResponse struct {
+	Origin     string            `json:"origin"`
+	Source     string            `json:"source"`
+	Metric     string            `json:"metric"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Score      int               `json:"score"`
+}
+
+// CatalogSearchListResponse represents a list of catalog search results response structure in the server backend.
+type CatalogSearchListResponse []*CatalogSearchResponse
+
+func (r CatalogSearchListResponse) Len() int {
+	return len(r)
+}
+
+func (r CatalogSearchListResponse) Less(i, j int) bool {
+	if r[i].Score != r[j].Score {
+		return r[i].Score > r[j].Score
+	} else if r[i].Origin != r[j].Origin {
+		return natsort.Compare(r[i].Origin, r[j].Origin)
+	} else if r[i].Source != r[j].Source {
+		return natsort.Compare(r[i].Source, r[j].Source)
+	}
+
+	return natsort.Compare(r[i].Metric, r[j].Metric)
+}
+
+func (r CatalogSearchListResponse) Swap(i, j int) {
+	r[i], r[j] = r[j], r[i]
+}
+
+func (r CatalogSearchListResponse) slice(i, j int) interface{} {
+	return r[i:j]
+}
+
 // StringListResponse represents a list of strings response structure in the server backend.
 type StringListResponse []string
 
@@ -206,6 +243,62 @@ func (r UnitValueListResponse) slice(i, j int) interface{} {
 	return r[i:j]
 }
 
+// ProviderResponse represents a provider response structure in the server backend.
+type ProviderResponse struct {
+	Name            string  `json:"name"`
+	Connector       string  `json:"connector"`
+	LastRefresh     string  `json:"last_refresh,omitempty"`
+	RefreshDuration float64 `json:"refresh_duration"`
+	RefreshRecords  int     `json:"refresh_records"`
+	RefreshCount    int     `json:"refresh_count"`
+	RefreshError    string  `json:"refresh_error,omitempty"`
+	Origins         int     `json:"origins"`
+	Sources         int     `json:"sources"`
+	Metrics         int     `json:"metrics"`
+	StaleMetrics    int     `json:"stale_metrics"`
+	Queries         int     `json:"queries"`
+	QueryErrors     int     `json:"query_errors"`
+	QueryErrorRate  float64 `json:"query_error_rate"`
+}
+
+// ProviderFiltersTestRequest represents a provider filters test request structure in the server backend.
+type ProviderFiltersTestRequest struct {
+	Records []*ProviderFiltersTestRecord `json:"records"`
+	Refresh bool                         `json:"refresh"`
+}
+
+// ProviderFiltersTestRecord represents a sample record of a provider filters test request.
+type ProviderFiltersTestRecord struct {
+	Origin string `json:"origin"`
+	Source string `json:"source"`
+	Metric string `json:"metric"`
+}
+
+// ProviderFiltersTestResponse represents a provider filters test response structure in the server backend.
+type ProviderFiltersTestResponse struct {
+	Traces []*provider.FilterTrace `json:"traces"`
+	Error  string                  `json:"error,omitempty"`
+}
+
+// ProviderListResponse represents a list of providers response structure in the backend server.
+type ProviderListResponse []*ProviderResponse
+
+func (r ProviderListResponse) Len() int {
+	return len(r)
+}
+
+func (r ProviderListResponse) Less(i, j int) bool {
+	return natsort.Compare(r[i].Name, r[j].Name)
+}
+
+func (r ProviderListResponse) Swap(i, j int) {
+	r[i], r[j] = r[j], r[i]
+}
+
+func (r ProviderListResponse) slice(i, j int) interface{} {
+	return r[i:j]
+}
+
 // PlotResponse represents a plot response structure in the server backend.
 type PlotResponse struct {
 	ID          string            `json:"id"`
@@ -219,6 +312,7 @@ type PlotResponse struct {
 	UnitType    int               `json:"unit_type"`
 	UnitLegend  string            `json:"unit_legend"`
 	Series      []*SeriesResponse `json:"series"`
+	Errors      []*SeriesError    `json:"errors,omitempty"`
 	Modified    time.Time         `json:"modified"`
 }
 
@@ -231,6 +325,12 @@ type SeriesResponse struct {
 	Options map[string]interface{} `json:"options"`
 }
 
+// SeriesError represents a series query failure in a plot response.
+type SeriesError struct {
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
 // Unexported types
 type listResponse struct {
 	list   sortableListResponse
@@ -249,20 +349,38 @@ type serverResponse struct {
 	Message string `json:"message"`
 }
 
+type healthResponse struct {
+	Message string `json:"message,omitempty"`
+	Catalog bool   `json:"catalog"`
+	Library bool   `json:"library"`
+}
+
 type statsResponse struct {
-	Origins      int `json:"origins"`
-	Sources      int `json:"sources"`
-	Metrics      int `json:"metrics"`
-	Graphs       int `json:"graphs"`
-	Collections  int `json:"collections"`
-	SourceGroups int `json:"sourcegroups"`
-	MetricGroups int `json:"metricgroups"`
+	Origins      int             `json:"origins"`
+	Sources      int             `json:"sources"`
+	Metrics      int             `json:"metrics"`
+	Graphs       int             `json:"graphs"`
+	Collections  int             `json:"collections"`
+	SourceGroups int             `json:"sourcegroups"`
+	MetricGroups int             `json:"metricgroups"`
+	PlotCache    *plotCacheStats `json:"plot_cache,omitempty"`
 }
 
 type providerQuery struct {
-	query     plot.Query
-	queryMap  []providerQueryMap
-	connector connector.Connector
+	query        plot.Query
+	queryMap     []providerQueryMap
+	provider     string
+	connector    connector.Connector
+	timeout      time.Duration
+	step         time.Duration
+	leadIn       time.Duration
+	windowPoints int
+	timeShift    time.Duration
+}
+
+type providerQueryResult struct {
+	plots []*plot.Series
+	err   error
 }
 
 type providerQueryMap struct {
@@ -270,4 +388,5 @@ type providerQueryMap struct {
 	sourceName      string
 	metricName      string
 	fromSourceGroup bool
+	timeShift       string
 }
@@ -1,19 +1,20 @@
 package server
 
 import (
-	"github.com/facette/facette/pkg/catalog"
+	"time"
+
 	"github.com/facette/facette/pkg/logger"
 	"github.com/facette/facette/pkg/worker"
 )
 
 func workerCatalogInit(w *worker.Worker, args ...interface{}) {
-	var catalog = args[0].(*catalog.Catalog)
+	var server = args[0].(*Server)
 
 	logger.Log(logger.LevelDebug, "catalogWorker", "init")
 
 	// Worker properties:
-	// 0: catalog instance (*catalog.Catalog)
-	w.Props = append(w.Props, catalog)
+	// 0: server instance (*Server)
+	w.Props = append(w.Props, server)
 
 	w.ReturnErr(nil)
 }
@@ -27,7 +28,10 @@ func workerCatalogShutdown(w *worker.Worker, args ...interface{}) {
 }
 
 func workerCatalogRun(w *worker.Worker, args ...interface{}) {
-	var serverCatalog = w.Props[0].(*catalog.Catalog)
+	var (
+		server       = w.Props[0].(*Server)
+		snapshotChan <-chan time.Time
+	)
 
 	defer w.Shutdown()
 
@@ -35,6 +39,14 @@ func workerCatalogRun(w *worker.Worker, args ...interface{}) {
 
 	w.State = worker.JobStarted
 
+	// Periodically save catalog snapshot if enabled
+	if server.Config.CatalogSnapshotInterval > 0 {
+		ticker := time.NewTicker(time.Duration(server.Config.CatalogSnapshotInterval) * time.Second)
+		defer ticker.Stop()
+
+		snapshotChan = ticker.C
+	}
+
 	for {
 		select {
 		case cmd := <-w.ReceiveJobSignals():
@@ -42,6 +54,12 @@ func workerCatalogRun(w *worker.Worker, args ...interface{}) {
 			case jobSignalShutdown:
 				logger.Log(logger.LevelInfo, "catalogWorker", "received shutdown command, stopping job")
 
+				if server.Config.CatalogSnapshotInterval > 0 {
+					if err := server.saveCatalogSnapshot(); err != nil {
+						logger.Log(logger.LevelError, "catalogWorker", "unable to save catalog snapshot: %s", err)
+					}
+				}
+
 				w.State = worker.JobStopped
 
 				return
@@ -50,8 +68,15 @@ func workerCatalogRun(w *worker.Worker, args ...interface{}) {
 				logger.Log(logger.LevelNotice, "catalogWorker", "received unknown command, ignoring")
 			}
 
-		case record := <-serverCatalog.RecordChan:
-			serverCatalog.Insert(record)
+		case record := <-server.Catalog.RecordChan:
+			server.Catalog.Insert(record)
+
+		case <-snapshotChan:
+			logger.Log(logger.LevelDebug, "catalogWorker", "saving catalog snapshot")
+
+			if err := server.saveCatalogSnapshot(); err != nil {
+				logger.Log(logger.LevelError, "catalogWorker", "unable to save catalog snapshot: %s", err)
+			}
 		}
 	}
 }
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -16,37 +17,53 @@ func (server *Server) startProviderWorkers() error {
 
 	logger.Log(logger.LevelDebug, "server", "declaring providers")
 
-	for _, prov := range server.providers {
-		connectorType, err := config.GetString(prov.Config.Connector, "type", true)
-		if err != nil {
-			return fmt.Errorf("provider `%s' connector: %s", prov.Name, err)
-		} else if _, ok := connector.Connectors[connectorType]; !ok {
-			return fmt.Errorf("provider `%s' uses unknown connector type `%s'", prov.Name, connectorType)
+	for _, prov := range server.getProviders() {
+		if err := server.startProviderWorker(prov); err != nil {
+			return err
 		}
+	}
+
+	return nil
+}
+
+func (server *Server) startProviderWorker(prov *provider.Provider) error {
+	connectorType, err := config.GetString(prov.Config.Connector, "type", true)
+	if err != nil {
+		return fmt.Errorf("provider `%s' connector: %s", prov.Name, err)
+	} else if _, ok := connector.Connectors[connectorType]; !ok {
+		return fmt.Errorf("provider `%s' uses unknown connector type `%s'", prov.Name, connectorType)
+	}
 
-		// Append server identifier to provider configuration
-		prov.Config.Connector["_id"] = server.ID
+	// Append server identifier to provider configuration
+	prov.Config.Connector["_id"] = server.ID
 
-		providerWorker := worker.NewWorker()
-		providerWorker.RegisterEvent(eventInit, workerProviderInit)
-		providerWorker.RegisterEvent(eventShutdown, workerProviderShutdown)
-		providerWorker.RegisterEvent(eventRun, workerProviderRun)
-		providerWorker.RegisterEvent(eventCatalogRefresh, workerProviderRefresh)
+	providerWorker := worker.NewWorker()
+	providerWorker.RegisterEvent(eventInit, workerProviderInit)
+	providerWorker.RegisterEvent(eventShutdown, workerProviderShutdown)
+	providerWorker.RegisterEvent(eventRun, workerProviderRun)
+	providerWorker.RegisterEvent(eventCatalogRefresh, workerProviderRefresh)
 
-		if err := providerWorker.SendEvent(eventInit, false, prov, connectorType); err != nil {
-			logger.Log(logger.LevelWarning, "server", "in provider `%s', %s", prov.Name, err)
-			logger.Log(logger.LevelWarning, "server", "discarding provider `%s'", prov.Name)
-			continue
-		}
+	if err := providerWorker.SendEvent(eventInit, false, prov, connectorType); err != nil {
+		logger.Log(logger.LevelWarning, "server", "in provider `%s', %s", prov.Name, err)
+		logger.Log(logger.LevelWarning, "server", "discarding provider `%s'", prov.Name)
 
-		// Add worker into pool if initialization went fine
-		server.providerWorkers.Add(providerWorker)
+		// Unregister discarded provider, as it would otherwise hold the catalog as not loaded forever
+		server.providersLock.Lock()
+		delete(server.providers, prov.Name)
+		server.providersLock.Unlock()
 
-		providerWorker.SendEvent(eventRun, true, nil)
+		prov.Close()
 
-		logger.Log(logger.LevelDebug, "server", "declared provider `%s'", prov.Name)
+		return nil
 	}
 
+	// Add worker into pool if initialization went fine
+	server.providerWorkers.Add(providerWorker)
+
+	providerWorker.SendEvent(eventRun, true, nil)
+
+	logger.Log(logger.LevelDebug, "server", "declared provider `%s'", prov.Name)
+
 	return nil
 }
 
@@ -57,9 +74,62 @@ func (server *Server) stopProviderWorkers() {
 	server.providerWorkers.Wg.Wait()
 
 	// Shut down providers filtering goroutine
+	for _, prov := range server.getProviders() {
+		prov.Close()
+	}
+}
+
+func (server *Server) stopProviderWorker(prov *provider.Provider) {
+	if providerWorker := server.getProviderWorker(prov.Name); providerWorker != nil {
+		providerWorker.SendEvent(eventShutdown, true, nil)
+		providerWorker.Wait()
+
+		server.providerWorkers.Remove(providerWorker)
+	}
+
+	// Shut down provider filtering goroutine, then purge its catalog entries
+	prov.Close()
+
+	if prov.Connector != nil {
+		count := server.Catalog.Purge(prov.Connector)
+		logger.Log(logger.LevelDebug, "server", "purged %d metrics from provider `%s'", count, prov.Name)
+	}
+
+	if server.plotCache != nil {
+		server.plotCache.purge(prov.Name)
+	}
+
+	logger.Log(logger.LevelDebug, "server", "stopped provider `%s'", prov.Name)
+}
+
+func (server *Server) getProvider(name string) (*provider.Provider, bool) {
+	server.providersLock.RLock()
+	defer server.providersLock.RUnlock()
+
+	prov, ok := server.providers[name]
+	return prov, ok
+}
+
+func (server *Server) getProviders() []*provider.Provider {
+	server.providersLock.RLock()
+	defer server.providersLock.RUnlock()
+
+	providers := make([]*provider.Provider, 0)
 	for _, prov := range server.providers {
-		close(prov.Filters.Input)
+		providers = append(providers, prov)
+	}
+
+	return providers
+}
+
+func (server *Server) getProviderWorker(name string) *worker.Worker {
+	for _, providerWorker := range server.providerWorkers.List() {
+		if len(providerWorker.Props) > 0 && providerWorker.Props[0].(*provider.Provider).Name == name {
+			return providerWorker
+		}
 	}
+
+	return nil
 }
 
 func workerProviderInit(w *worker.Worker, args ...interface{}) {
@@ -79,24 +149,41 @@ func workerProviderInit(w *worker.Worker, args ...interface{}) {
 
 	prov.Connector = conn.(connector.Connector)
 
+	// Let connectors detecting backend changes by themselves request catalog refreshes
+	if requester, ok := conn.(connector.RefreshRequester); ok {
+		requester.SetRefreshRequest(prov.RequestRefresh)
+	}
+
+	// Create context used to cancel in-flight refresh operations on shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+
 	// Worker properties:
 	// 0: provider instance (*provider.Provider)
-	w.Props = append(w.Props, prov)
+	// 1: refresh context (context.Context)
+	// 2: refresh context cancellation function (context.CancelFunc)
+	w.Props = append(w.Props, prov, ctx, cancel)
 
 	w.ReturnErr(nil)
 }
 
 func workerProviderShutdown(w *worker.Worker, args ...interface{}) {
-	var prov = w.Props[0].(*provider.Provider)
+	var (
+		prov   = w.Props[0].(*provider.Provider)
+		cancel = w.Props[2].(context.CancelFunc)
+	)
 
 	logger.Log(logger.LevelDebug, "provider", "%s: shutdown", prov.Name)
 
+	// Abort refresh operation if any
+	cancel()
+
 	w.SendJobSignal(jobSignalShutdown)
 }
 
 func workerProviderRun(w *worker.Worker, args ...interface{}) {
 	var (
 		prov       = w.Props[0].(*provider.Provider)
+		ctx        = w.Props[1].(context.Context)
 		timeTicker *time.Ticker
 		timeChan   <-chan time.Time
 	)
@@ -117,25 +204,26 @@ func workerProviderRun(w *worker.Worker, args ...interface{}) {
 		case _ = <-timeChan:
 			logger.Log(logger.LevelDebug, "provider", "%s: performing refresh from connector", prov.Name)
 
-			if err := prov.Connector.Refresh(prov.Name, prov.Filters.Input); err != nil {
+			if err := prov.Refresh(ctx); err != nil {
 				logger.Log(logger.LevelError, "provider", "%s: unable to refresh: %s", prov.Name, err)
-				continue
 			}
 
-			prov.LastRefresh = time.Now()
+		case _ = <-prov.RefreshRequests():
+			logger.Log(logger.LevelDebug, "provider", "%s: performing refresh requested by connector", prov.Name)
+
+			if err := prov.Refresh(ctx); err != nil {
+				logger.Log(logger.LevelError, "provider", "%s: unable to refresh: %s", prov.Name, err)
+			}
 
 		case cmd := <-w.ReceiveJobSignals():
 			switch cmd {
 			case jobSignalRefresh:
 				logger.Log(logger.LevelInfo, "provider", "%s: received refresh command", prov.Name)
 
-				if err := prov.Connector.Refresh(prov.Name, prov.Filters.Input); err != nil {
+				if err := prov.Refresh(ctx); err != nil {
 					logger.Log(logger.LevelError, "provider", "%s: unable to refresh: %s", prov.Name, err)
-					continue
 				}
 
-				prov.LastRefresh = time.Now()
-
 			case jobSignalShutdown:
 				logger.Log(logger.LevelInfo, "provider", "%s: received shutdown command, stopping job", prov.Name)
 
@@ -12,14 +12,16 @@ import (
 )
 
 const (
-	urlStaticPath  string = "/static/"
-	urlAdminPath   string = "/admin/"
-	urlBrowsePath  string = "/browse/"
-	urlShowPath    string = "/show/"
-	urlCatalogPath string = "/api/v1/catalog/"
-	urlLibraryPath string = "/api/v1/library/"
-	urlPlotsPath   string = "/api/v1/plots"
-	urlStatsPath   string = "/api/v1/stats"
+	urlStaticPath    string = "/static/"
+	urlAdminPath     string = "/admin/"
+	urlBrowsePath    string = "/browse/"
+	urlShowPath      string = "/show/"
+	urlCatalogPath   string = "/api/v1/catalog/"
+	urlLibraryPath   string = "/api/v1/library/"
+	urlPlotsPath     string = "/api/v1/plots"
+	urlStatsPath     string = "/api/v1/stats"
+	urlProvidersPath string = "/api/v1/providers/"
+	urlHealthPath    string = "/health"
 )
 
 func workerServeInit(w *worker.Worker, args ...interface{}) {
@@ -60,6 +62,8 @@ func workerServeRun(w *worker.Worker, args ...interface{}) {
 	router.HandleFunc(urlBrowsePath, server.serveBrowse)
 	router.HandleFunc(urlShowPath, server.serveShow)
 	router.HandleFunc(urlStatsPath, server.serveStats)
+	router.HandleFunc(urlProvidersPath, server.serveProviders)
+	router.HandleFunc(urlHealthPath, server.serveHealth)
 
 	router.HandleFunc("/", server.serveBrowse)
 
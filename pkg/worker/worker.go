@@ -24,6 +24,7 @@ type Worker struct {
 	eventChan chan workerEvent
 	jobChan   chan int
 	errorChan chan error
+	doneChan  chan struct{}
 	wg        *sync.WaitGroup
 }
 
@@ -31,6 +32,7 @@ type Worker struct {
 type Pool struct {
 	Workers []*Worker
 	Wg      *sync.WaitGroup
+	lock    *sync.RWMutex
 }
 
 type workerEvent struct {
@@ -48,6 +50,7 @@ func NewWorker() *Worker {
 		eventChan: make(chan workerEvent),
 		jobChan:   make(chan int),
 		errorChan: make(chan error),
+		doneChan:  make(chan struct{}),
 	}
 
 	go func(worker *Worker) {
@@ -109,31 +112,62 @@ func (worker *Worker) Shutdown() {
 	close(worker.eventChan)
 	close(worker.jobChan)
 	close(worker.errorChan)
+	close(worker.doneChan)
 
 	if worker.wg != nil {
 		worker.wg.Done()
 	}
 }
 
+// Wait waits for the worker to be shut down.
+func (worker *Worker) Wait() {
+	<-worker.doneChan
+}
+
 // NewPool creates a new worker pool.
 func NewPool() Pool {
 	return Pool{
 		Workers: make([]*Worker, 0),
 		Wg:      &sync.WaitGroup{},
+		lock:    &sync.RWMutex{},
 	}
 }
 
 // Add adds worker to the worker pool.
 func (workerPool *Pool) Add(worker *Worker) {
+	workerPool.lock.Lock()
+	defer workerPool.lock.Unlock()
+
 	workerPool.Wg.Add(1)
 	worker.wg = workerPool.Wg
 
 	workerPool.Workers = append(workerPool.Workers, worker)
 }
 
+// Remove removes worker from the worker pool.
+func (workerPool *Pool) Remove(worker *Worker) {
+	workerPool.lock.Lock()
+	defer workerPool.lock.Unlock()
+
+	for i, item := range workerPool.Workers {
+		if item == worker {
+			workerPool.Workers = append(workerPool.Workers[:i], workerPool.Workers[i+1:]...)
+			break
+		}
+	}
+}
+
+// List returns a copy of the list of workers of the worker pool.
+func (workerPool *Pool) List() []*Worker {
+	workerPool.lock.RLock()
+	defer workerPool.lock.RUnlock()
+
+	return append([]*Worker(nil), workerPool.Workers...)
+}
+
 // Broadcast sends an event to all workers of the worker pool.
-func (workerPool Pool) Broadcast(event int, args ...interface{}) {
-	for _, worker := range workerPool.Workers {
+func (workerPool *Pool) Broadcast(event int, args ...interface{}) {
+	for _, worker := range workerPool.List() {
 		worker.SendEvent(event, true, args...)
 	}
 }